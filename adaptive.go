@@ -0,0 +1,106 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// chunk sizes StrategyAdaptive explores, from 64KB up to 64MB
+const (
+	adaptiveMinChunkSize = 64 * 1024
+	adaptiveMaxChunkSize = 64 * 1024 * 1024
+)
+
+// a bigger chunk size is only kept when it reads at least 10% faster
+const adaptiveGain = 1.1
+
+// readAdaptive reads size bytes of src into data for StrategyAdaptive.
+// The file is read in waves of one chunk per worker, the chunk size
+// doubling after every wave as long as the throughput of the wave
+// improves. Once it plateaus, the rest of the file is read in one go
+// with the fastest chunk size seen. order, when not nil, is given
+// the chunks as they complete.
+func (r *Reader) readAdaptive(ctx context.Context, path string, src io.ReaderAt, base, size int64, data []byte, order *chunkOrder) (Stats, error) {
+	stats := Stats{Strategy: StrategyAdaptive}
+
+	chunkSize := int64(adaptiveMinChunkSize)
+	best, bestThroughput := chunkSize, 0.0
+	growing := true
+	eta := newETAEstimator()
+
+	for done := int64(0); done < size; {
+		length := size - done
+		if wave := chunkSize * int64(r.concurrency()); growing && wave < length {
+			length = wave
+		}
+
+		start := done
+		hooks := chunkHooks{
+			buffer: func(offset, length int64) []byte {
+				return data[start+offset : start+offset+length]
+			},
+			base: base + start,
+			path: path,
+		}
+		if order != nil {
+			hooks.handle = func(offset int64, chunk []byte) error {
+				order.complete(start+offset, int64(len(chunk)))
+				return nil
+			}
+		}
+
+		began := time.Now()
+		waveStats, err := r.wave(chunkSize, start, size, eta).readChunks(ctx, io.NewSectionReader(src, start, length), length, hooks)
+		elapsed := time.Since(began)
+
+		stats.BytesRead += waveStats.BytesRead
+		stats.ChunkCount += waveStats.ChunkCount
+		stats.ChunkDurations = append(stats.ChunkDurations, waveStats.ChunkDurations...)
+		if waveStats.GoroutinesUsed > stats.GoroutinesUsed {
+			stats.GoroutinesUsed = waveStats.GoroutinesUsed
+		}
+		stats.ChunkSize = chunkSize
+		if err != nil {
+			return stats, err
+		}
+		done += length
+
+		if !growing {
+			continue
+		}
+
+		throughput := float64(length) / (elapsed.Seconds() + 1e-9)
+		if throughput >= bestThroughput*adaptiveGain {
+			best, bestThroughput = chunkSize, throughput
+			if chunkSize*2 <= adaptiveMaxChunkSize {
+				chunkSize *= 2
+				continue
+			}
+		}
+
+		// the throughput plateaued, keep the best size for the rest
+		growing = false
+		chunkSize = best
+		r.cfg.Logger.Printf("filereader: adaptive chunk size settled on %d bytes", chunkSize)
+	}
+	return stats, nil
+}
+
+// wave returns a copy of r reading chunks of chunkSize bytes, for the
+// wave of an adaptive read starting done bytes into its total bytes.
+// The progress is reported for the whole read, with the eta estimated
+// over all the waves.
+func (r *Reader) wave(chunkSize, done, total int64, eta *etaEstimator) *Reader {
+	cfg := r.cfg
+	cfg.ChunkSize = chunkSize
+	if progress := r.cfg.Progress; progress != nil {
+		cfg.Progress = func(bytesDone, _ int64, _ time.Duration) {
+			progress(done+bytesDone, total, eta.estimate(done+bytesDone, total))
+		}
+	}
+
+	return r.derive(cfg)
+}
@@ -0,0 +1,62 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// latencyReaderAt takes the same time for every read, whatever its
+// size, like a disk dominated by its seeks.
+type latencyReaderAt struct {
+	latency time.Duration
+}
+
+func (l latencyReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	time.Sleep(l.latency)
+	return len(p), nil
+}
+
+func TestAdaptiveGrowsChunks(t *testing.T) {
+	const size = 16 << 20
+	r := NewReader(ReaderConfig{SyncThreshold: -1, Strategy: StrategyAdaptive, Concurrency: 4})
+
+	stats, err := r.readAdaptive(context.Background(), "", latencyReaderAt{2 * time.Millisecond}, 0, size, make([]byte, size), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.BytesRead != size || stats.ChunkSize <= adaptiveMinChunkSize {
+		t.Errorf("read %d bytes and settled on %d byte chunks, want more than %d", stats.BytesRead, stats.ChunkSize, adaptiveMinChunkSize)
+	}
+}
+
+// BenchmarkReadAsyncAdaptive reports the chunk size StrategyAdaptive
+// settles on next to the fixed sizes it picks from.
+func BenchmarkReadAsyncAdaptive(b *testing.B) {
+	const size = 64 << 20
+	path := writeTmp(b, randData(size))
+
+	bench := func(cfg ReaderConfig) func(b *testing.B) {
+		return func(b *testing.B) {
+			r := NewReader(cfg)
+			b.SetBytes(size)
+			var chunkSize int64
+			for i := 0; i < b.N; i++ {
+				_, stats, err := r.ReadAsyncStats(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				chunkSize = stats.ChunkSize
+			}
+			b.ReportMetric(float64(chunkSize), "chunk-bytes")
+		}
+	}
+
+	b.Run("adaptive", bench(ReaderConfig{Strategy: StrategyAdaptive}))
+	for cs := int64(adaptiveMinChunkSize); cs <= 16<<20; cs *= 4 {
+		b.Run(fmt.Sprintf("fixed-%dKB", cs>>10), bench(ReaderConfig{ChunkSize: cs}))
+	}
+}
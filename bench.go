@@ -0,0 +1,334 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+)
+
+// ErrReadMismatch is returned by ReadBoth and VerifyConsistency when
+// the two reads of the file didn't see the same bytes.
+var ErrReadMismatch = errors.New("filereader: synchronous and asynchronous reads disagree")
+
+// ErrInvalidIterations is returned by Compare when iterations is below 1.
+var ErrInvalidIterations = errors.New("filereader: iterations must be at least 1")
+
+// BenchResult is the outcome of one sync vs async comparison.
+type BenchResult struct {
+	// SyncDuration and AsyncDuration are the time taken by
+	// ReadSyncStats and ReadAsyncStats on the file,
+	// encoded as nanoseconds in JSON.
+	SyncDuration  time.Duration
+	AsyncDuration time.Duration
+
+	// Speedup is SyncDuration / AsyncDuration,
+	// above 1 the asynchronous read was faster.
+	Speedup float64
+
+	// FileSize is the size of the file in bytes.
+	FileSize int64
+
+	// ChunkSize is the chunk size of the asynchronous read.
+	ChunkSize int64
+}
+
+// Benchmark reads the file at path synchronously then asynchronously
+// and reports how long each read took.
+func Benchmark(path string) (BenchResult, error) {
+	return defaultReader.Benchmark(path)
+}
+
+// Benchmark is the package level Benchmark using r's config.
+func (r *Reader) Benchmark(path string) (BenchResult, error) {
+	fileStats, err := os.Stat(longPath(path))
+	if err != nil {
+		return BenchResult{}, readErr(path, PhaseStat, -1, err)
+	}
+
+	syncStats, err := r.ReadSyncStats(path)
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	_, asyncStats, err := r.ReadAsyncStats(path)
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	result := BenchResult{
+		SyncDuration:  syncStats.Duration,
+		AsyncDuration: asyncStats.Duration,
+		FileSize:      fileStats.Size(),
+		ChunkSize:     r.cfg.ChunkSize,
+	}
+	if asyncStats.Duration > 0 {
+		result.Speedup = float64(syncStats.Duration) / float64(asyncStats.Duration)
+	}
+	return result, nil
+}
+
+// ReadBoth reads the file at path synchronously, then asynchronously,
+// and returns the Stats of the asynchronous read.
+//
+// The synchronous scanner moves the offset of the file it reads while the
+// asynchronous read only does positioned reads (ReadAt), so sharing one
+// handle would make the second read depend on the first. Each read opens
+// a fresh handle of its own instead, and both must see the same number
+// of bytes, otherwise ReadBoth fails with ErrReadMismatch.
+//
+// The synchronous read always runs first, and leaves the file in the page
+// cache for the asynchronous one, see Compare for a fairer comparison.
+func ReadBoth(path string) (Stats, error) {
+	return defaultReader.ReadBoth(path)
+}
+
+// ReadBoth is the package level ReadBoth using r's config.
+func (r *Reader) ReadBoth(path string) (Stats, error) {
+	syncStats, err := r.ReadSyncStats(path)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	_, asyncStats, err := r.ReadAsyncStats(path)
+	if err != nil {
+		return asyncStats, err
+	}
+
+	if syncStats.BytesRead != asyncStats.BytesRead {
+		err := fmt.Errorf("%w: %d bytes read synchronously, %d asynchronously",
+			ErrReadMismatch, syncStats.BytesRead, asyncStats.BytesRead)
+		return asyncStats, readErr(path, PhaseRead, -1, err)
+	}
+	return asyncStats, nil
+}
+
+// VerifyConsistency reads the file at path asynchronously, then
+// sequentially, and checks both reads returned the same bytes. The
+// returned error is then a ReadError wrapping ErrReadMismatch, its Offset
+// the one of the first difference. It is a self check of the chunked read:
+// the file is read in chunks whatever its size, below the SyncThreshold
+// and with StrategySequential too.
+func VerifyConsistency(path string) error {
+	return defaultReader.VerifyConsistency(path)
+}
+
+// VerifyConsistency is the package level VerifyConsistency using r's config.
+func (r *Reader) VerifyConsistency(path string) error {
+	cfg := r.cfg
+	cfg.SyncThreshold = -1
+	if cfg.Strategy == StrategySequential {
+		cfg.Strategy = StrategyReadAt
+	}
+
+	got, err := r.derive(cfg).ReadAsync(path)
+	if err != nil {
+		return err
+	}
+
+	src, err := r.openSequential(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// compare the sequential read block by block,
+	// so the file is only held in memory once
+	buf := make([]byte, syncBufferSize)
+	offset := 0
+	for {
+		n, err := io.ReadFull(src, buf)
+		if want := buf[:n]; !bytes.HasPrefix(got[offset:], want) {
+			i := 0
+			for i < len(got)-offset && got[offset+i] == want[i] {
+				i++
+			}
+			err := fmt.Errorf("%w: first difference", ErrReadMismatch)
+			return readErr(path, PhaseRead, int64(offset+i), err)
+		}
+		offset += n
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return readErr(path, PhaseRead, int64(offset), err)
+		}
+	}
+
+	if offset != len(got) {
+		err := fmt.Errorf("%w: first difference, the asynchronous read has %d more bytes",
+			ErrReadMismatch, len(got)-offset)
+		return readErr(path, PhaseRead, int64(offset), err)
+	}
+	return nil
+}
+
+// CompareResult is the outcome of Compare.
+type CompareResult struct {
+	// Sync and Async summarize the durations of every
+	// ReadSyncStats and ReadAsyncStats run.
+	Sync  DurationSummary
+	Async DurationSummary
+
+	// Speedup is Sync.Median / Async.Median,
+	// above 1 the asynchronous read was faster.
+	Speedup float64
+
+	// Iterations is the number of runs of each read.
+	Iterations int
+
+	// FileSize is the size of the file in bytes.
+	FileSize int64
+
+	// ChunkSize is the chunk size of the asynchronous read.
+	ChunkSize int64
+}
+
+// DurationSummary is the spread of the durations of repeated runs.
+type DurationSummary struct {
+	Min    time.Duration
+	Median time.Duration
+	Max    time.Duration
+}
+
+// Compare reads the file at path iterations times synchronously and
+// iterations times asynchronously and summarizes the durations.
+//
+// Whichever read runs first warms the page cache for the other, so
+// every iteration runs both reads in a random order, and the median
+// is less sensitive to a cold first run than a single measure.
+func Compare(path string, iterations int) (CompareResult, error) {
+	return defaultReader.Compare(path, iterations)
+}
+
+// Compare is the package level Compare using r's config.
+func (r *Reader) Compare(path string, iterations int) (CompareResult, error) {
+	if iterations < 1 {
+		return CompareResult{}, ErrInvalidIterations
+	}
+
+	fileStats, err := os.Stat(longPath(path))
+	if err != nil {
+		return CompareResult{}, readErr(path, PhaseStat, -1, err)
+	}
+
+	syncDurations := make([]time.Duration, 0, iterations)
+	asyncDurations := make([]time.Duration, 0, iterations)
+
+	runSync := func() error {
+		stats, err := r.ReadSyncStats(path)
+		syncDurations = append(syncDurations, stats.Duration)
+		return err
+	}
+	runAsync := func() error {
+		_, stats, err := r.ReadAsyncStats(path)
+		asyncDurations = append(asyncDurations, stats.Duration)
+		return err
+	}
+
+	for i := 0; i < iterations; i++ {
+		first, second := runSync, runAsync
+		if rand.Intn(2) == 0 {
+			first, second = second, first
+		}
+		if err := first(); err != nil {
+			return CompareResult{}, err
+		}
+		if err := second(); err != nil {
+			return CompareResult{}, err
+		}
+	}
+
+	result := CompareResult{
+		Sync:       summarize(syncDurations),
+		Async:      summarize(asyncDurations),
+		Iterations: iterations,
+		FileSize:   fileStats.Size(),
+		ChunkSize:  r.cfg.ChunkSize,
+	}
+	if result.Async.Median > 0 {
+		result.Speedup = float64(result.Sync.Median) / float64(result.Async.Median)
+	}
+	return result, nil
+}
+
+// summarize sorts durations and returns their min, median and max.
+func summarize(durations []time.Duration) DurationSummary {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	n := len(durations)
+	median := durations[n/2]
+	if n%2 == 0 {
+		median = (durations[n/2-1] + durations[n/2]) / 2
+	}
+	return DurationSummary{Min: durations[0], Median: median, Max: durations[n-1]}
+}
+
+// chunk sizes TuneChunkSize tries when given none, the powers of two
+// StrategyAdaptive explores
+var tuneChunkSizes = func() []int64 {
+	var sizes []int64
+	for size := int64(adaptiveMinChunkSize); size <= adaptiveMaxChunkSize; size *= 2 {
+		sizes = append(sizes, size)
+	}
+	return sizes
+}()
+
+// TuneChunkSize reads the file at path asynchronously once per chunk
+// size of candidates, the powers of two from 64KB to 64MB when it is
+// empty, and returns the fastest one with the time of every read.
+//
+// The file is read once before the timed reads, so all of them find it
+// in the page cache and the first candidate isn't the only one paying
+// for the disk: the durations measure the chunking, not the storage.
+// Files below the SyncThreshold are read sequentially whatever the
+// chunk size, StrategyAdaptive is replaced by StrategyReadAt.
+func TuneChunkSize(path string, candidates []int64) (int64, map[int64]time.Duration, error) {
+	return defaultReader.TuneChunkSize(path, candidates)
+}
+
+// TuneChunkSize is the package level TuneChunkSize using r's config.
+func (r *Reader) TuneChunkSize(path string, candidates []int64) (int64, map[int64]time.Duration, error) {
+	if err := r.validate(); err != nil {
+		return 0, nil, err
+	}
+	if len(candidates) == 0 {
+		candidates = tuneChunkSizes
+	}
+	for _, size := range candidates {
+		if size <= 0 {
+			return 0, nil, fmt.Errorf("%w: %d", ErrInvalidChunkSize, size)
+		}
+	}
+
+	// warm the page cache
+	if _, err := r.ReadAsync(path); err != nil {
+		return 0, nil, err
+	}
+
+	best := int64(0)
+	results := make(map[int64]time.Duration, len(candidates))
+	for _, size := range candidates {
+		cfg := r.cfg
+		cfg.ChunkSize = size
+		if cfg.Strategy == StrategyAdaptive {
+			cfg.Strategy = StrategyReadAt
+		}
+		_, stats, err := r.derive(cfg).ReadAsyncStats(path)
+		if err != nil {
+			return 0, nil, err
+		}
+		results[size] = stats.Duration
+		if best == 0 || stats.Duration < results[best] {
+			best = size
+		}
+	}
+	return best, results, nil
+}
@@ -0,0 +1,48 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestVerifyConsistencyReadsChunks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(path, make([]byte, 5000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, strategy := range []Strategy{StrategyReadAt, StrategySequential} {
+		// well below the default SyncThreshold
+		var chunks int64
+		r := NewReader(ReaderConfig{
+			ChunkSize: 1024,
+			Strategy:  strategy,
+			OnChunk:   func(offset int64, data []byte) { atomic.AddInt64(&chunks, 1) },
+		})
+		if err := r.VerifyConsistency(path); err != nil {
+			t.Fatal(err)
+		}
+		if chunks != 5 {
+			t.Errorf("%v: the asynchronous read gave %d chunks, want 5", strategy, chunks)
+		}
+	}
+}
+
+func TestBenchmarkMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+
+	_, err := Benchmark(path)
+	var re *ReadError
+	if !errors.As(err, &re) || re.Phase != PhaseStat || re.Path != path || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Benchmark = %v, want a ReadError in PhaseStat", err)
+	}
+	_, err = Compare(path, 1)
+	if !errors.As(err, &re) || re.Phase != PhaseStat || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Compare = %v, want a ReadError in PhaseStat", err)
+	}
+}
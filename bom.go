@@ -0,0 +1,84 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUTF16 is returned with ReaderConfig.StripBOM when the data starts
+// with a UTF-16 byte order mark, as only UTF-8 text is handled.
+var ErrUTF16 = errors.New("filereader: data is UTF-16 encoded, only UTF-8 is supported")
+
+// byte order marks
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// bomLength returns the length of the UTF-8 byte order mark prefix
+// starts with, when r strips them.
+func (r *Reader) bomLength(prefix []byte) (int64, error) {
+	if !r.cfg.StripBOM {
+		return 0, nil
+	}
+
+	switch {
+	case bytes.HasPrefix(prefix, bomUTF8):
+		return int64(len(bomUTF8)), nil
+	case bytes.HasPrefix(prefix, bomUTF16LE):
+		return 0, fmt.Errorf("%w (little endian byte order mark)", ErrUTF16)
+	case bytes.HasPrefix(prefix, bomUTF16BE):
+		return 0, fmt.Errorf("%w (big endian byte order mark)", ErrUTF16)
+	}
+	return 0, nil
+}
+
+// skipBOM returns the length of the byte order mark src starts with,
+// if any. Reading the chunks from there (and aligning them on it) makes
+// the offsets seen by the callers the ones of the stripped data.
+func (r *Reader) skipBOM(src io.ReaderAt) (int64, error) {
+	if !r.cfg.StripBOM {
+		return 0, nil
+	}
+
+	prefix := make([]byte, len(bomUTF8))
+	n, err := src.ReadAt(prefix, 0)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	return r.bomLength(prefix[:n])
+}
+
+// stripBOMReader is skipBOM for sources read sequentially.
+func (r *Reader) stripBOMReader(src io.Reader) (io.Reader, error) {
+	if !r.cfg.StripBOM {
+		return src, nil
+	}
+
+	buffered := bufio.NewReader(src)
+
+	// fewer bytes than a mark is not an error, there is no mark then
+	prefix, err := buffered.Peek(len(bomUTF8))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	skip, err := r.bomLength(prefix)
+	if err != nil {
+		return nil, err
+	}
+	buffered.Discard(int(skip))
+	return buffered, nil
+}
+
+// readCloser reads from Reader and closes Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,162 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownChecksum is returned by Checksum for an unsupported algorithm.
+var ErrUnknownChecksum = errors.New("filereader: unknown checksum algorithm")
+
+// ChecksumAlgo is a checksum algorithm supported by Checksum.
+type ChecksumAlgo int
+
+const (
+	// ChecksumCRC32 is the IEEE CRC-32, returned as 4 big endian bytes.
+	ChecksumCRC32 ChecksumAlgo = iota
+
+	// ChecksumSHA256 is SHA-256, returned as 32 bytes.
+	ChecksumSHA256
+)
+
+// Checksum computes the checksum of the file at path in the same
+// concurrent pass as the read, so the file is read only once.
+//
+// CRC-32 is computed per chunk by the workers and the chunk results are
+// combined afterwards. SHA-256 can't be split that way, the chunks are
+// fed to the hash in file order as they arrive. An empty file has the
+// checksum of no bytes, 0 for CRC-32.
+func Checksum(path string, algo ChecksumAlgo) ([]byte, error) {
+	return defaultReader.Checksum(path, algo)
+}
+
+// Checksum is the package level Checksum using r's config.
+func (r *Reader) Checksum(path string, algo ChecksumAlgo) ([]byte, error) {
+	switch algo {
+	case ChecksumCRC32:
+		return r.checksumCRC32(path)
+	case ChecksumSHA256:
+		return r.checksumSHA256(path)
+	}
+	return nil, ErrUnknownChecksum
+}
+
+func (r *Reader) checksumSHA256(path string) ([]byte, error) {
+	hash := sha256.New()
+	err := r.ReadAsyncStream(path, func(offset int64, data []byte) error {
+		hash.Write(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+func (r *Reader) checksumCRC32(path string) ([]byte, error) {
+	type chunkCRC struct {
+		offset int64
+		length int64
+		crc    uint32
+	}
+
+	var mu sync.Mutex
+	var chunks []chunkCRC
+
+	err := r.ScanAsync(path, func(offset int64, data []byte) error {
+		crc := crc32.ChecksumIEEE(data)
+
+		mu.Lock()
+		chunks = append(chunks, chunkCRC{offset, int64(len(data)), crc})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// fold the chunk checksums in file order
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].offset < chunks[j].offset })
+
+	var crc uint32
+	for _, c := range chunks {
+		crc = crc32Combine(crc, c.crc, c.length)
+	}
+
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc)
+	return sum, nil
+}
+
+// crc32Combine returns the IEEE CRC-32 of A followed by B given
+// crc1 = CRC-32(A), crc2 = CRC-32(B) and len2 = len(B).
+// Port of crc32_combine from zlib: appending len2 zero bytes to A
+// is applied to crc1 as a linear operator over GF(2), by squaring
+// a 32x32 bit matrix.
+func crc32Combine(crc1, crc2 uint32, len2 int64) uint32 {
+	if len2 <= 0 {
+		return crc1
+	}
+
+	even := make([]uint32, 32) // even power of two zeros operator
+	odd := make([]uint32, 32)  // odd power of two zeros operator
+
+	// operator for one zero bit in odd
+	odd[0] = crc32.IEEE
+	row := uint32(1)
+	for n := 1; n < 32; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+
+	// operator for two zero bits in even, then four zero bits in odd
+	gf2MatrixSquare(even, odd)
+	gf2MatrixSquare(odd, even)
+
+	// apply len2 zeros to crc1, the first square puts the operator
+	// for one zero byte, eight zero bits, in even
+	for {
+		gf2MatrixSquare(even, odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+
+		gf2MatrixSquare(odd, even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+
+	return crc1 ^ crc2
+}
+
+func gf2MatrixTimes(mat []uint32, vec uint32) uint32 {
+	var sum uint32
+	for i := 0; vec != 0; i++ {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+		vec >>= 1
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square, mat []uint32) {
+	for n := 0; n < 32; n++ {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
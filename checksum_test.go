@@ -0,0 +1,40 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	for _, size := range []int{0, 1, 999, 1000, 1001, 25*1000 + 17} {
+		path := writeTmp(t, randData(size))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantSHA := sha256.Sum256(data)
+		wantCRC := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(data))
+
+		for _, cs := range []int64{1, 7, 1000} {
+			r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: cs})
+			sum, err := r.Checksum(path, ChecksumSHA256)
+			if err != nil || !bytes.Equal(sum, wantSHA[:]) {
+				t.Errorf("SHA-256 of %d bytes in %d byte chunks = %x, %v, want %x", size, cs, sum, err, wantSHA)
+			}
+			sum, err = r.Checksum(path, ChecksumCRC32)
+			if err != nil || !bytes.Equal(sum, wantCRC) {
+				t.Errorf("CRC-32 of %d bytes in %d byte chunks = %x, %v, want %x", size, cs, sum, err, wantCRC)
+			}
+		}
+	}
+
+	if _, err := Checksum(writeTmp(t, nil), ChecksumAlgo(-1)); err != ErrUnknownChecksum {
+		t.Errorf("Checksum with an unknown algorithm = %v, want ErrUnknownChecksum", err)
+	}
+}
@@ -0,0 +1,343 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+// Command filereader compares the time taken for synchronous
+// and asynchronous reading of a file, or of every file matched
+// by the -f patterns. -mode sync or -mode async times one of
+// the reads only. -tune times the asynchronous read with every chunk
+// size from 64KB to 64MB instead, and prints which is the fastest.
+// -cat prints the files, read asynchronously, on stdout like cat.
+//
+// When no file is given, or the file is "-", it reads stdin with
+// the synchronous reader only.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	filereader "github.com/bigfoot31/fastFileReader"
+)
+
+// files collects the -f flags, every one a path or a glob pattern.
+type files []string
+
+func (f *files) String() string { return strings.Join(*f, ", ") }
+
+func (f *files) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// options are the command line flags shared by every file.
+type options struct {
+	jsonOutput bool
+	runs       int
+	mode       string
+	printLine  func(number int64, line []byte) error
+}
+
+// values of -mode, the reads to time
+const (
+	modeSync  = "sync"
+	modeAsync = "async"
+	modeBoth  = "both"
+)
+
+func main() {
+	// command line args
+	var patterns files
+	flag.Var(&patterns, "f", "path to file or glob pattern like logs/*.txt, can be repeated; empty or - reads stdin")
+	jsonOutput := flag.Bool("json", false, "print the benchmark result as JSON on stdout")
+	numbered := flag.Bool("n", false, "print every line of the syncronous read on stdout with its line number")
+	chunk := flag.String("chunk", "", "chunk size of the asyncronous read, like 4MB or 512KB (default 1MB)")
+	runs := flag.Int("runs", 1, "read the file this many times each way, in random order, and report min/median/max")
+	mode := flag.String("mode", modeBoth, "reads to time: sync, async or both")
+	verifyFlag := flag.Bool("verify", false, "check the asyncronous read returns the bytes of the syncronous one, print OK or the first differing offset")
+	catFlag := flag.Bool("cat", false, "print the content of the files, read asyncronously and in order, on stdout instead of timing the reads")
+	tuneFlag := flag.Bool("tune", false, "time the asyncronous read with every chunk size from 64KB to 64MB and print a table, the fastest marked")
+
+	flag.Parse()
+
+	switch *mode {
+	case modeSync, modeAsync, modeBoth:
+	default:
+		usageError("invalid -mode: " + *mode)
+	}
+	if *runs > 1 && *mode != modeBoth {
+		usageError("-runs compares both reads, it needs -mode both")
+	}
+	if *tuneFlag && *verifyFlag {
+		usageError("-tune and -verify can't be used together")
+	}
+	// the content is all -cat prints on stdout
+	if *catFlag && (*tuneFlag || *verifyFlag || *jsonOutput || *numbered) {
+		usageError("-cat can't be used with -tune, -verify, -json or -n")
+	}
+
+	var cfg filereader.ReaderConfig
+	if *chunk != "" {
+		size, err := parseSize(*chunk)
+		if err != nil {
+			usageError("invalid -chunk: " + err.Error())
+		}
+		cfg.ChunkSize = size
+	}
+	reader := filereader.NewReader(cfg)
+
+	opts := options{jsonOutput: *jsonOutput, runs: *runs, mode: *mode}
+
+	// with -n the sync read prints its lines, which is part of its time
+	out := bufio.NewWriter(os.Stdout)
+	if *numbered {
+		opts.printLine = func(number int64, line []byte) error {
+			_, err := fmt.Fprintf(out, "%6d\t%s\n", number, line)
+			return err
+		}
+	}
+
+	// stdin is not seekable, so only the sync path can read it
+	if len(patterns) == 0 || (len(patterns) == 1 && (patterns[0] == "" || patterns[0] == "-")) {
+		if *catFlag {
+			if _, err := io.Copy(os.Stdout, os.Stdin); err != nil {
+				log.Fatal("cannot able to copy stdin ", err)
+			}
+			return
+		}
+		if *jsonOutput {
+			log.Fatal("-json needs a file to benchmark")
+		}
+		if *mode == modeAsync {
+			log.Fatal("stdin is not seekable, it can only be read with -mode sync")
+		}
+
+		log.Println("reading stdin, skipping asyncronous file reading as stdin is not seekable")
+
+		syncStats, err := reader.ReadSyncLinesFrom(os.Stdin, opts.printLine)
+		out.Flush()
+		if err != nil {
+			log.Fatal("cannot able to read stdin ", err)
+		}
+		log.Println("time taken for syncronous stdin reading", syncStats.Duration)
+		return
+	}
+
+	run := benchmark
+	if *verifyFlag {
+		run = verify
+	}
+	if *tuneFlag {
+		run = tune
+	}
+	if *catFlag {
+		run = cat
+	}
+
+	// keep going past the files which fail, but exit with an error
+	failed := false
+	for _, path := range expand(patterns) {
+		if !*catFlag && (len(patterns) > 1 || path != patterns[0]) {
+			log.Println("file", path)
+		}
+		if err := run(reader, path, opts); err != nil {
+			log.Println("cannot able to read the file", path, err)
+			failed = true
+		}
+		out.Flush()
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// expand returns the files matched by patterns, in order, without the
+// directories. A pattern matching nothing is kept as it is so reading it
+// reports why.
+func expand(patterns []string) []string {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil || len(matches) == 0 {
+			paths = append(paths, pattern)
+			continue
+		}
+
+		for _, path := range matches {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				continue
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// benchmark compares the syncronous and asyncronous reads of the file at
+// path and prints the summary.
+func benchmark(reader *filereader.Reader, path string, opts options) error {
+	if opts.runs > 1 {
+		result, err := reader.Compare(path, opts.runs)
+		if err != nil {
+			return err
+		}
+		if opts.jsonOutput {
+			return json.NewEncoder(os.Stdout).Encode(result)
+		}
+		log.Println("syncronous file reading over", result.Iterations, "runs: min", result.Sync.Min,
+			"median", result.Sync.Median, "max", result.Sync.Max)
+		log.Println("asyncronous file reading over", result.Iterations, "runs: min", result.Async.Min,
+			"median", result.Async.Median, "max", result.Async.Max)
+		log.Printf("speedup %.2fx", result.Speedup)
+		return nil
+	}
+
+	if opts.jsonOutput && opts.mode == modeBoth {
+		result, err := reader.Benchmark(path)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+
+	// a single read prints its Stats with -json
+	if opts.mode != modeAsync {
+		syncStats, err := reader.ReadSyncLines(path, opts.printLine)
+		if err != nil {
+			return err
+		}
+		if opts.jsonOutput {
+			return json.NewEncoder(os.Stdout).Encode(syncStats)
+		}
+		log.Println("time taken for syncronous file reading", syncStats.Duration)
+	}
+
+	if opts.mode != modeSync {
+		_, asyncStats, err := reader.ReadAsyncStats(path)
+		if err != nil {
+			return err
+		}
+		if opts.jsonOutput {
+			return json.NewEncoder(os.Stdout).Encode(asyncStats)
+		}
+		log.Println("time taken for asyncronous file reading", asyncStats.Duration,
+			"using", asyncStats.GoroutinesUsed, "goroutines for", asyncStats.ChunkCount, "chunks",
+			"("+asyncStats.Strategy.String()+")")
+	}
+	return nil
+}
+
+// usageError reports a bad command line and exits.
+func usageError(msg string) {
+	fmt.Fprintln(flag.CommandLine.Output(), msg)
+	flag.Usage()
+	os.Exit(2)
+}
+
+// verify checks the asyncronous read of the file at path against the
+// syncronous one and prints OK. The error of a mismatch tells the first
+// offset at which they differ.
+func verify(reader *filereader.Reader, path string, opts options) error {
+	if err := reader.VerifyConsistency(path); err != nil {
+		return err
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// cat writes the content of the file at path to stdout. The chunks are
+// read concurrently and written in order as soon as they can be, the
+// file is never held in memory whole.
+func cat(reader *filereader.Reader, path string, opts options) error {
+	file, err := reader.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteTo(os.Stdout)
+	return err
+}
+
+// tune times the asyncronous read of the file at path with every chunk
+// size TuneChunkSize tries and prints them in a table.
+func tune(reader *filereader.Reader, path string, opts options) error {
+	best, results, err := reader.TuneChunkSize(path, nil)
+	if err != nil {
+		return err
+	}
+
+	sizes := make([]int64, 0, len(results))
+	for size := range results {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "chunk size\ttime\t")
+	for _, size := range sizes {
+		mark := ""
+		if size == best {
+			mark = "fastest"
+		}
+		fmt.Fprintf(w, "%s\t%v\t%s\n", formatSize(size), results[size], mark)
+	}
+	return w.Flush()
+}
+
+// size units accepted by -chunk, in powers of 1024 like the defaults
+// of the package (1MB is 1024*1024 bytes)
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a human readable size like 4MB, 512kb or 4096.
+func parseSize(s string) (int64, error) {
+	number, unit := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(number, u.suffix) {
+			number, unit = strings.TrimSpace(strings.TrimSuffix(number, u.suffix)), u.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a size", s)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%q must be positive", s)
+	}
+	if n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("%q is too large", s)
+	}
+	return n * unit, nil
+}
+
+// formatSize formats size with the largest unit dividing it, like 4MB.
+func formatSize(size int64) string {
+	for _, u := range sizeUnits[:3] {
+		if size%u.bytes == 0 {
+			return strconv.FormatInt(size/u.bytes, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10)
+}
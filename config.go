@@ -0,0 +1,457 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrInvalidChunkSize is returned when ReaderConfig.ChunkSize is negative.
+var ErrInvalidChunkSize = errors.New("filereader: chunk size must be positive")
+
+// ErrInvalidConcurrency is returned when ReaderConfig.Concurrency is negative.
+var ErrInvalidConcurrency = errors.New("filereader: concurrency must be at least 1")
+
+// ErrInvalidReadAhead is returned when ReaderConfig.ReadAheadChunks
+// is negative.
+var ErrInvalidReadAhead = errors.New("filereader: read ahead must be at least 1 chunk")
+
+// ErrInvalidStrategy is returned when ReaderConfig.Strategy is unknown.
+var ErrInvalidStrategy = errors.New("filereader: unknown strategy")
+
+// ErrInvalidMaxLineSize is returned when ReaderConfig.MaxLineSize is negative.
+var ErrInvalidMaxLineSize = errors.New("filereader: max line size must be positive")
+
+// ErrInvalidMaxAttempts is returned when ReaderConfig.MaxAttempts is negative.
+var ErrInvalidMaxAttempts = errors.New("filereader: max attempts must be at least 1")
+
+// ErrInvalidAlignment is returned when ReaderConfig.AlignTo is negative.
+var ErrInvalidAlignment = errors.New("filereader: alignment must be positive")
+
+// ErrInvalidMaxBytes is returned when ReaderConfig.MaxBytes is negative.
+var ErrInvalidMaxBytes = errors.New("filereader: max bytes must be positive")
+
+// ErrInvalidMaxBytesPerSec is returned when ReaderConfig.MaxBytesPerSec
+// is negative.
+var ErrInvalidMaxBytesPerSec = errors.New("filereader: max bytes per second must be positive")
+
+// ReaderConfig holds the tunables of a Reader.
+// The zero value is ready to use and gives the package defaults.
+type ReaderConfig struct {
+	// ChunkSize is the number of bytes each asynchronous job reads.
+	// 0 means the 1MB default.
+	ChunkSize int64
+
+	// AlignTo, when not 0, aligns the chunk offsets on multiples of it
+	// in the file, typically the block size of the file system (4096),
+	// as aligned reads are cheaper for some devices and direct I/O.
+	// ChunkSize is rounded up to a multiple of AlignTo, and a read
+	// starting at an unaligned offset, like ReadRange, gets a shorter
+	// first chunk so every following chunk starts aligned; the cost is
+	// one more, smaller, ReadAt.
+	AlignTo int64
+
+	// Concurrency is the number of chunks read at the same time.
+	// High latency file systems (NFS, FUSE...) benefit from more reads
+	// in flight than cpus, local SSDs may prefer less.
+	// 0 means runtime.GOMAXPROCS(0), the cpus the program may use
+	// rather than the ones of the machine, so a container limited to
+	// a few cpus doesn't start a worker per core of its host. Go 1.25
+	// derives it from the cgroup CPU limit, older versions need the
+	// GOMAXPROCS environment variable (or automaxprocs) set to it.
+	Concurrency int
+
+	// ReadAheadChunks is the number of chunks the in order reads (Open,
+	// ReadAsyncStream...) read ahead of their consumer, waiting in memory
+	// to be consumed. More keeps a fast disk busy while the consumer is
+	// slow, at the cost of ChunkSize bytes each. 0 means twice the
+	// Concurrency.
+	ReadAheadChunks int
+
+	// MaxAttempts is the number of times the read of a chunk is tried
+	// before giving up, transient errors of network file systems can
+	// be worth a retry. 0 means 1, no retry.
+	MaxAttempts int
+
+	// RetryBackoff is the wait before the first retry of a chunk, it
+	// doubles after every failed attempt. 0 means 100ms.
+	RetryBackoff time.Duration
+
+	// ChunkTimeout, when not 0, abandons the read of a chunk taking
+	// longer than it, like on a hung network mount, which then fails
+	// with ErrChunkTimeout (or is retried, see MaxAttempts). A ReadAt
+	// can't be interrupted: the abandoned call keeps running in the
+	// background until the system call returns, and every chunk is
+	// read into a buffer of its own then copied, which costs a copy.
+	ChunkTimeout time.Duration
+
+	// Strategy is how files are read, StrategyReadAt by default.
+	// Stats.Strategy tells what was actually used, as small or
+	// compressed files may be read sequentially whatever the strategy.
+	Strategy Strategy
+
+	// Gzip makes the reader decompress files starting with the gzip
+	// magic bytes (1f 8b), other files are read as they are.
+	//
+	// A gzip stream can't be read at random offsets, so compressed
+	// files are always read sequentially by a single goroutine, even
+	// by the asynchronous functions.
+	Gzip bool
+
+	// StripBOM makes the reader drop the UTF-8 byte order mark
+	// (EF BB BF) a file may start with, so it doesn't leak into the
+	// data or the first line. The offsets given to the callbacks are
+	// then the ones of the data without the mark. A file starting
+	// with a UTF-16 byte order mark fails with ErrUTF16.
+	StripBOM bool
+
+	// NoFollowSymlinks rejects the paths which are symlinks with
+	// ErrSymlinkNotAllowed instead of reading the file they point to,
+	// for servers reading paths given by their users. Only the last
+	// element of the path is checked, not its directories. Symlinks
+	// are followed by default.
+	NoFollowSymlinks bool
+
+	// SyncThreshold is the file size under which the asynchronous
+	// reads of whole files (ReadAsync, ReadAsyncFrom, ReadAll) read
+	// the file sequentially instead, as for small files the goroutines
+	// and channels cost more than they save. Stats.Strategy tells
+	// which path ran. 0 means the 4MB default, a negative value
+	// always reads asynchronously.
+	SyncThreshold int64
+
+	// MaxBytes, when not 0, is the largest input the reads returning
+	// the whole data in memory (ReadAsync, ReadAsyncFrom, ReadRange,
+	// ReadAll...) accept. Larger ones fail with ErrFileTooLarge before
+	// the output is allocated, so an untrusted huge file can't exhaust
+	// the memory. Gzip streams fail once MaxBytes were decompressed.
+	MaxBytes int64
+
+	// MaxBytesPerSec, when not 0, caps the rate at which the chunks are
+	// read, across all the workers and all the reads of the Reader, so
+	// reading a huge file doesn't saturate a shared disk. Every chunk
+	// waits for its bytes in a token bucket before it is read.
+	// Sequential reads are not throttled.
+	MaxBytesPerSec int64
+
+	// MaxLineSize is the longest line the synchronous line scanner
+	// accepts, longer lines fail the read with an error wrapping
+	// bufio.ErrTooLong. 0 means the 512kB default.
+	MaxLineSize int
+
+	// Split is the split function of the synchronous scanner, for
+	// instance bufio.ScanWords to tokenize whitespace separated data
+	// or bufio.ScanRunes. nil means bufio.ScanLines. With another
+	// split function the "lines" of ReadLines are its tokens.
+	Split bufio.SplitFunc
+
+	// EOL is the end of line the line reads (ReadLines, ReadLinesAsync,
+	// Head, Tail, ReadSync...) split at, EOLAuto by default. It is
+	// ignored by the scanner when Split is set. CountLines and Grep
+	// always split at '\n'.
+	EOL EOL
+
+	// Transform, when not nil, is applied to every line of the line
+	// reads (ReadLines, ReadLinesAsync, Head, Tail, and the lines
+	// ReadSyncLines passes to its fn) once it is split, without its end
+	// of line, and the line read is the one it returns. It may modify
+	// the line in place and return it, but must not keep it. The lines
+	// are still returned in file order, but ReadLinesAsync transforms
+	// them from its workers, so Transform must be safe for concurrent
+	// calls.
+	Transform func(line []byte) []byte
+
+	// Stable makes the reader stat the file again once it has been
+	// read and fail with ErrFileChanged if its size changed meanwhile.
+	//
+	// The size of the file is only taken once, before the chunks are
+	// dispatched: bytes appended during the read are missed, and a
+	// truncated file makes the late chunks fail with
+	// io.ErrUnexpectedEOF. Stable turns the first case into an error
+	// too, which is useful when reading logs still being written.
+	// With StrategyMmap a truncation crashes the program instead, see
+	// StrategyMmap.
+	Stable bool
+
+	// Hint tells the kernel how the chunked reads access the file,
+	// HintNone by default. It only has an effect on linux.
+	Hint Hint
+
+	// ReverseOrder starts the chunk reads from the end of the file,
+	// the last chunk first, for measuring how the read ahead of the
+	// operating system helps. The data is the same, only the order of
+	// the reads changes. The in order reads (Open, ReadAsyncStream) keep
+	// reading forward.
+	ReverseOrder bool
+
+	// ChunkTiming makes the asynchronous reads time the ReadAt of every
+	// chunk and return the durations in Stats.ChunkDurations.
+	ChunkTiming bool
+
+	// Progress, when not nil, is called as the chunks of an
+	// asynchronous read complete with the number of bytes done so far,
+	// the total to read and an estimate of the time left. Calls are
+	// serialized and throttled to about a hundred per read; the last
+	// call always has bytesDone == total.
+	//
+	// eta comes from the throughput smoothed over the calls, so a single
+	// slow or fast chunk doesn't make it jump. It is 0 at the end, and
+	// until the throughput is known.
+	Progress func(bytesDone, total int64, eta time.Duration)
+
+	// OnChunk, when not nil, is called with every chunk of the reads
+	// returning the whole data (ReadAsync, ReadAsyncInto, ReadRange...),
+	// for folding the data as it is read: hashing it, counting, copying
+	// it somewhere else. offset is the one of the chunk in the data.
+	//
+	// The chunks are read in any order but OnChunk sees them in offset
+	// order, each one starting where the previous one ended, and calls
+	// are serialized: a chunk read early waits for the ones before it.
+	// data is part of the output of the read and must not be modified.
+	// A read which fails stops calling it. Sequential reads make a
+	// single call with all the data.
+	OnChunk func(offset int64, data []byte)
+
+	// HTTPClient is the client of ReadAsyncURL, set it to control
+	// timeouts or transports. nil means http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Logger receives the diagnostics of the reader, like failing
+	// chunks. nil discards them.
+	Logger Logger
+
+	// Metrics, when not nil, is given the measures of the reads.
+	Metrics Metrics
+}
+
+// default ReaderConfig.SyncThreshold, 4MB
+const defaultSyncThreshold = 4 * 1024 * 1024
+
+// default ReaderConfig.RetryBackoff
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// Strategy is the way a file is read.
+type Strategy int
+
+const (
+	// StrategyReadAt splits the file into chunks read concurrently
+	// with positioned reads (ReadAt). It is the default.
+	StrategyReadAt Strategy = iota
+
+	// StrategySequential reads the file from start to end
+	// with a single goroutine.
+	StrategySequential
+
+	// StrategyMmap maps the file in memory and lets the workers copy
+	// the chunks out of the mapping, which can beat ReadAt on huge
+	// files. Platforms without mmap fall back to StrategyReadAt.
+	//
+	// A file truncated while mapped doesn't fail the read like with
+	// the other strategies: touching the pages past its new end raises
+	// SIGBUS, which crashes the program. Don't use it on files that
+	// other processes may truncate, Stable doesn't help there.
+	StrategyMmap
+
+	// StrategyAdaptive reads the file like StrategyReadAt but picks the
+	// chunk size itself: it starts small and grows the chunks wave after
+	// wave until the throughput stops improving. Stats.ChunkSize tells
+	// the size it settled on. Only ReadAsync and friends, which read the
+	// whole file at once, adapt; other reads use ChunkSize.
+	StrategyAdaptive
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case StrategyReadAt:
+		return "readat"
+	case StrategySequential:
+		return "sequential"
+	case StrategyMmap:
+		return "mmap"
+	case StrategyAdaptive:
+		return "adaptive"
+	}
+	return "unknown"
+}
+
+// Logger is the logging interface used by a Reader,
+// a *log.Logger satisfies it. The workers of a read log from their
+// own goroutines, so Printf must be safe for concurrent use.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// nopLogger discards everything, it is the default Logger.
+type nopLogger struct{}
+
+func (nopLogger) Printf(format string, v ...interface{}) {}
+
+// Metrics receives the measures of the reads of a Reader, for exporting
+// them to a metrics system (Prometheus...) without this package
+// depending on one. The methods are called from several goroutines,
+// they must be safe for concurrent use and fast.
+type Metrics interface {
+	// ObserveRead is called once a read of a whole file (ReadAsync,
+	// ReadSync...) succeeded, with the bytes read and its duration.
+	ObserveRead(bytes int64, dur time.Duration)
+
+	// IncChunkError is called for every chunk whose read failed, after
+	// its retries. The chunks stopped by a cancellation are not counted.
+	IncChunkError()
+}
+
+// nopMetrics discards everything, it is the default Metrics.
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRead(bytes int64, dur time.Duration) {}
+func (nopMetrics) IncChunkError()                             {}
+
+// Reader reads files using the settings of its ReaderConfig.
+// A Reader holds no per-read state, so one Reader can be used
+// by many goroutines at the same time: every read gets its own
+// workers, output and reorder state, and what the reads share (the
+// buffer pool, the limiters) is safe for concurrent use. The callbacks
+// of the config are serialized within a read, but the reads overlapping
+// on one Reader call them concurrently.
+//
+// A Reader returned by Open is also bound to one open file, which
+// its io.Reader and io.WriterTo implementations read. That binding is
+// not safe for concurrent use.
+type Reader struct {
+	cfg ReaderConfig
+
+	// chunk buffers reused by the streaming reads,
+	// each one is a *[]byte of cfg.ChunkSize bytes.
+	buffers sync.Pool
+
+	// limiter, when not nil, bounds the number of chunk reads in
+	// flight across every read sharing it: the files of a ReadAll,
+	// or the Readers of a ReaderPool.
+	limiter chan struct{}
+
+	// rate throttles the chunk reads to MaxBytesPerSec,
+	// nil when they are not throttled
+	rate *rate.Limiter
+
+	// file bound by Open, nil otherwise
+	file      *os.File
+	fileStats os.FileInfo
+
+	// in order stream over file, started by the first Read or WriteTo
+	stream *stream
+}
+
+// defaultReader backs the package level functions.
+var defaultReader = NewReader(ReaderConfig{})
+
+// NewReader returns a Reader using cfg, zero fields are replaced by
+// their defaults. The config is validated when a read is started.
+func NewReader(cfg ReaderConfig) *Reader {
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = asyncChunkSize
+	}
+	if cfg.AlignTo > 0 && cfg.ChunkSize > 0 && cfg.ChunkSize%cfg.AlignTo != 0 {
+		cfg.ChunkSize += cfg.AlignTo - cfg.ChunkSize%cfg.AlignTo
+	}
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = runtime.GOMAXPROCS(0)
+	}
+	if cfg.ReadAheadChunks == 0 {
+		cfg.ReadAheadChunks = 2 * cfg.Concurrency
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+	if cfg.MaxLineSize == 0 {
+		cfg.MaxLineSize = syncBufferSize
+	}
+	if cfg.SyncThreshold == 0 {
+		cfg.SyncThreshold = defaultSyncThreshold
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = nopLogger{}
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = nopMetrics{}
+	}
+
+	r := &Reader{cfg: cfg}
+	if cfg.MaxBytesPerSec > 0 {
+		// the bucket holds one chunk at most, so
+		// the rate holds from the first chunks on
+		burst := cfg.MaxBytesPerSec
+		if cfg.ChunkSize > 0 && cfg.ChunkSize < burst {
+			burst = cfg.ChunkSize
+		}
+		r.rate = rate.NewLimiter(rate.Limit(cfg.MaxBytesPerSec), int(burst))
+	}
+	r.buffers.New = func() interface{} {
+		buf := make([]byte, r.cfg.ChunkSize)
+		return &buf
+	}
+	return r
+}
+
+// derive returns a Reader using cfg, a variation of r's config, which
+// shares the limiters of r.
+func (r *Reader) derive(cfg ReaderConfig) *Reader {
+	d := NewReader(cfg)
+	d.limiter = r.limiter
+	d.rate = r.rate
+	return d
+}
+
+// validate checks the config before any file is touched.
+func (r *Reader) validate() error {
+	if r.cfg.ChunkSize <= 0 {
+		return ErrInvalidChunkSize
+	}
+	if r.cfg.AlignTo < 0 {
+		return ErrInvalidAlignment
+	}
+	if r.cfg.Concurrency < 1 {
+		return ErrInvalidConcurrency
+	}
+	if r.cfg.ReadAheadChunks < 1 {
+		return ErrInvalidReadAhead
+	}
+	if r.cfg.MaxAttempts < 1 {
+		return ErrInvalidMaxAttempts
+	}
+	if r.cfg.Strategy < StrategyReadAt || r.cfg.Strategy > StrategyAdaptive {
+		return ErrInvalidStrategy
+	}
+	if r.cfg.EOL < EOLAuto || r.cfg.EOL > EOLCRLF {
+		return ErrInvalidEOL
+	}
+	if r.cfg.Hint < HintNone || r.cfg.Hint > HintWillNeed {
+		return ErrInvalidHint
+	}
+	if r.cfg.MaxBytes < 0 {
+		return ErrInvalidMaxBytes
+	}
+	if r.cfg.MaxBytesPerSec < 0 {
+		return ErrInvalidMaxBytesPerSec
+	}
+	if r.cfg.MaxLineSize <= 0 {
+		return ErrInvalidMaxLineSize
+	}
+	return nil
+}
+
+// concurrency returns the number of workers reading chunks at the
+// same time.
+func (r *Reader) concurrency() int {
+	return r.cfg.Concurrency
+}
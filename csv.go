@@ -0,0 +1,89 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+// ReadCSV returns the records of the CSV file at path, fields separated
+// by comma, like the ReadAll of an encoding/csv Reader.
+//
+// The chunks are parsed concurrently, every worker parsing the records
+// lying entirely in its chunk, and the records crossing a chunk boundary
+// are put back together like the lines of ReadLinesAsync. That only
+// works when records end at newlines: a quoted field holding a newline
+// can be split between two chunks. The pieces of such a field, like any
+// other error, fail to parse, and the file is then parsed again from the
+// start sequentially by encoding/csv, which returns either the right
+// records or its own error.
+func ReadCSV(path string, comma rune) ([][]string, error) {
+	return defaultReader.ReadCSV(path, comma)
+}
+
+// ReadCSV is the package level ReadCSV using r's config.
+func (r *Reader) ReadCSV(path string, comma rune) ([][]string, error) {
+	records := [][]string{}
+	failed := false
+
+	err := r.scanLineChunks(path, func(block []byte, start int64) interface{} {
+		found, err := parseCSV(block, comma)
+		if err != nil {
+			return nil
+		}
+		return found
+	}, func(line []byte, start int64) {
+		found, err := parseCSV(line, comma)
+		if err != nil {
+			failed = true
+		}
+		records = append(records, found...)
+	}, func(result interface{}) {
+		found, ok := result.([][]string)
+		if !ok {
+			failed = true
+		}
+		records = append(records, found...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// encoding/csv wants every record to have as many
+	// fields as the first one
+	for _, record := range records {
+		if len(record) != len(records[0]) {
+			failed = true
+			break
+		}
+	}
+	if !failed {
+		return records, nil
+	}
+
+	src, err := r.openSequential(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	reader := csv.NewReader(src)
+	reader.Comma = comma
+	records, err = reader.ReadAll()
+	if err != nil {
+		return nil, readErr(path, PhaseRead, -1, err)
+	}
+	if records == nil {
+		records = [][]string{}
+	}
+	return records, nil
+}
+
+// parseCSV parses the records of data, whatever their number of fields.
+func parseCSV(data []byte, comma rune) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
@@ -0,0 +1,66 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVMatchesEncodingCSV(t *testing.T) {
+	var generated strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&generated, "%d,name %d,\"quoted, with a comma\",%d.5\n", i, i, i*7)
+	}
+
+	for _, tt := range []struct {
+		text  string
+		comma rune
+	}{
+		{"", ','},
+		{"a,b,c", ','},
+		{"a,b,c\n1,2,3\n", ','},
+		{"a;b\r\n1;2\r\n", ';'},
+		{"a,\"b \"\"quoted\"\"\"\n1,2\n", ','},
+		// a newline in a quoted field
+		{"a,\"multi\nline\"\n1,2\n3,4\n", ','},
+		{"a\tb\n1\t2\n", '\t'},
+		{generated.String(), ','},
+	} {
+		reader := csv.NewReader(strings.NewReader(tt.text))
+		reader.Comma = tt.comma
+		want, err := reader.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := writeTmp(t, []byte(tt.text))
+
+		for _, cs := range []int64{1, 5, 64, 4096} {
+			r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: cs})
+			records, err := r.ReadCSV(path, tt.comma)
+			if err != nil || !slices.EqualFunc(records, want, slices.Equal[[]string]) {
+				t.Errorf("ReadCSV(%.30q) with %d byte chunks = %d records, %v, want %d", tt.text, cs, len(records), err, len(want))
+			}
+		}
+	}
+}
+
+func TestReadCSVParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.csv")
+	if err := os.WriteFile(path, []byte("a,b\n\"c,d\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadCSV(path, ',')
+	var re *ReadError
+	var pe *csv.ParseError
+	if !errors.As(err, &re) || re.Phase != PhaseRead || re.Path != path || !errors.As(err, &pe) {
+		t.Fatalf("ReadCSV = %v, want a ReadError wrapping a csv.ParseError", err)
+	}
+}
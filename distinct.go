@@ -0,0 +1,117 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// ApproxDistinctLines estimates the number of distinct lines in the file
+// at path with a HyperLogLog sketch of the line hashes, using a few KB
+// of memory whatever the size of the file. The estimate is usually
+// within 1% of the exact count, always within a few percent.
+//
+// Lines are split at '\n' and a trailing '\r' is dropped, like Grep.
+// Every chunk is hashed by its worker into a sketch of its own and the
+// chunk sketches are merged at the end.
+func ApproxDistinctLines(path string) (int64, error) {
+	return defaultReader.ApproxDistinctLines(path)
+}
+
+// ApproxDistinctLines is the package level ApproxDistinctLines using r's config.
+func (r *Reader) ApproxDistinctLines(path string) (int64, error) {
+	total := newHLL()
+
+	err := r.scanLineChunks(path, func(block []byte, start int64) interface{} {
+		sketch := newHLL()
+		for len(block) > 0 {
+			end := bytes.IndexByte(block, '\n')
+			sketch.add(block[:end])
+			block = block[end+1:]
+		}
+		return sketch
+	}, func(line []byte, start int64) {
+		total.add(line)
+	}, func(result interface{}) {
+		total.merge(result.(*hll))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total.estimate(), nil
+}
+
+// the sketch has 2^hllPrecision registers, for a standard
+// error of 1.04/sqrt(2^hllPrecision), about 0.8%
+const hllPrecision = 14
+
+// hll is a HyperLogLog sketch, every register keeps the highest rank
+// of the hashes falling into it.
+type hll struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func newHLL() *hll {
+	return &hll{}
+}
+
+// add adds line, without its '\r', to the sketch.
+func (s *hll) add(line []byte) {
+	h := fnv.New64a()
+	h.Write(bytes.TrimSuffix(line, []byte{'\r'}))
+	sum := mix64(h.Sum64())
+
+	// the first bits pick the register, the rank is the position
+	// of the first set bit in the others
+	index := sum >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(sum<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > s.registers[index] {
+		s.registers[index] = rank
+	}
+}
+
+// merge adds the hashes of other to the sketch.
+func (s *hll) merge(other *hll) {
+	for i, rank := range other.registers {
+		if rank > s.registers[i] {
+			s.registers[i] = rank
+		}
+	}
+}
+
+// estimate returns the number of distinct hashes added to the sketch.
+func (s *hll) estimate() int64 {
+	m := float64(len(s.registers))
+
+	sum, zeros := 0.0, 0
+	for _, rank := range s.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	if zeros == len(s.registers) {
+		return 0
+	}
+
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// few hashes leave empty registers, counting them is more accurate
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// mix64 spreads the bits of the FNV hash, whose high bits vary little
+// between close lines, over the whole 64 bits.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
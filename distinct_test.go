@@ -0,0 +1,32 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestApproxDistinctLines(t *testing.T) {
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 64 * 1024})
+	for _, distinct := range []int{0, 1, 100, 1000, 50 * 1000, 200 * 1000} {
+		// every line twice, the second time ended by "\r\n"
+		var text strings.Builder
+		for i := 0; i < distinct; i++ {
+			fmt.Fprintf(&text, "line %d\n", i)
+		}
+		for i := 0; i < distinct; i++ {
+			fmt.Fprintf(&text, "line %d\r\n", i)
+		}
+
+		got, err := r.ApproxDistinctLines(writeTmp(t, []byte(text.String())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := math.Abs(float64(got - int64(distinct))); diff > 0.03*float64(distinct) {
+			t.Errorf("ApproxDistinctLines of %d distinct lines = %d, off by %.1f%%", distinct, got, 100*diff/float64(distinct))
+		}
+	}
+}
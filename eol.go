@@ -0,0 +1,71 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+)
+
+// ErrInvalidEOL is returned when ReaderConfig.EOL is unknown.
+var ErrInvalidEOL = errors.New("filereader: unknown end of line")
+
+// EOL is the end of line the line reads split at.
+type EOL int
+
+const (
+	// EOLAuto splits at '\n' and drops a '\r' before it, so both unix
+	// and windows files give clean lines. It is the default, and the
+	// split of bufio.ScanLines.
+	EOLAuto EOL = iota
+
+	// EOLLF splits at '\n' only, a '\r' before it is part of the line.
+	EOLLF
+
+	// EOLCRLF splits at "\r\n" only, a lone '\n' is part of the line.
+	EOLCRLF
+)
+
+var crlf = []byte("\r\n")
+
+// split returns the split function of the line scanner for eol.
+func (eol EOL) split() bufio.SplitFunc {
+	switch eol {
+	case EOLLF:
+		return scanLinesLF
+	case EOLCRLF:
+		return scanLinesCRLF
+	}
+	return bufio.ScanLines
+}
+
+// scanLinesLF is bufio.ScanLines keeping the '\r' before the '\n'.
+func scanLinesLF(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	// the last line, without a newline
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// scanLinesCRLF is bufio.ScanLines for lines ended by "\r\n" only.
+func scanLinesCRLF(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.Index(data, crlf); i >= 0 {
+		return i + len(crlf), data[:i], nil
+	}
+	// the last line, without a newline
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,142 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Equal tells whether the files at pathA and pathB hold the same bytes.
+// When they don't, it also returns the offset of the first difference.
+// The offset is -1 for equal files.
+//
+// Files of different sizes are told apart by their stats alone, without
+// reading them: the offset is then the size of the shorter file, past
+// which the files differ for sure, even when they differ before it.
+// Files of the same size are compared chunk by chunk concurrently with
+// ReadAt, and no chunk after a difference is read. The bytes are compared as they
+// are in the files, gzip compressed files are not decompressed.
+func Equal(pathA, pathB string) (bool, int64, error) {
+	return defaultReader.Equal(pathA, pathB)
+}
+
+// Equal is the package level Equal using r's config.
+func (r *Reader) Equal(pathA, pathB string) (bool, int64, error) {
+	if err := r.validate(); err != nil {
+		return false, 0, err
+	}
+
+	fileA, statsA, err := r.openFile(pathA)
+	if err != nil {
+		return false, 0, err
+	}
+	defer fileA.Close()
+
+	fileB, statsB, err := r.openFile(pathB)
+	if err != nil {
+		return false, 0, err
+	}
+	defer fileB.Close()
+
+	// finding the first difference of files of different sizes would
+	// mean reading all they have in common, the shorter file differs
+	// by ending anyway
+	if statsA.Size() != statsB.Size() {
+		shorter := statsA.Size()
+		if statsB.Size() < shorter {
+			shorter = statsB.Size()
+		}
+		return false, shorter, nil
+	}
+
+	diff, err := r.firstDifference(pathA, fileA, pathB, fileB, statsA.Size())
+	if err != nil {
+		return false, 0, err
+	}
+	return diff < 0, diff, nil
+}
+
+// firstDifference returns the offset of the first byte of the size
+// first bytes where a and b, the files at pathA and pathB, differ,
+// -1 when they don't.
+func (r *Reader) firstDifference(pathA string, a io.ReaderAt, pathB string, b io.ReaderAt, size int64) (int64, error) {
+	grid := r.chunkGrid(0, size)
+
+	// lowest offset of a difference found yet, -1 for none. The chunks
+	// after it can't hold the first difference, they are not read.
+	diff := int64(-1)
+	after := func(offset int64) bool {
+		d := atomic.LoadInt64(&diff)
+		return d >= 0 && offset > d
+	}
+
+	// the group cancels this context on the first error
+	// so no more chunks are dispatched.
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(r.concurrency())
+
+	for i := 0; i < grid.count; i++ {
+		offset := grid.offset(i)
+		if ctx.Err() != nil || after(offset) {
+			break
+		}
+
+		length := size - offset
+		if i+1 < grid.count {
+			length = grid.offset(i+1) - offset
+		}
+
+		g.Go(func() error {
+			if after(offset) {
+				return nil
+			}
+
+			// a slot of the limiter of a ReaderPool for both reads
+			if r.limiter != nil {
+				select {
+				case <-ctx.Done():
+					return nil
+				case r.limiter <- struct{}{}:
+				}
+				defer func() { <-r.limiter }()
+			}
+
+			bufA, bufB := r.getBuffer(offset, length), r.getBuffer(offset, length)
+			defer r.putBuffer(bufA)
+			defer r.putBuffer(bufB)
+
+			if _, err := io.ReadFull(io.NewSectionReader(a, offset, length), bufA); err != nil {
+				return readErr(pathA, PhaseRead, offset, err)
+			}
+			if _, err := io.ReadFull(io.NewSectionReader(b, offset, length), bufB); err != nil {
+				return readErr(pathB, PhaseRead, offset, err)
+			}
+			if bytes.Equal(bufA, bufB) {
+				return nil
+			}
+
+			j := 0
+			for bufA[j] == bufB[j] {
+				j++
+			}
+			// keep the lowest difference
+			for d := offset + int64(j); ; {
+				old := atomic.LoadInt64(&diff)
+				if old >= 0 && old <= d || atomic.CompareAndSwapInt64(&diff, old, d) {
+					break
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return 0, err
+	}
+	return diff, nil
+}
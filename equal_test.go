@@ -0,0 +1,56 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+// changed returns a copy of data with the byte at offset changed.
+func changed(data []byte, offset int) []byte {
+	c := append([]byte(nil), data...)
+	c[offset]++
+	return c
+}
+
+func TestEqual(t *testing.T) {
+	const cs = 1000
+	data := randData(10*cs + 7)
+	r := NewReader(ReaderConfig{ChunkSize: cs})
+
+	for _, tt := range []struct {
+		name   string
+		a, b   []byte
+		equal  bool
+		offset int64
+	}{
+		{"identical", data, data, true, -1},
+		{"empty", nil, nil, true, -1},
+		{"first chunk", data, changed(data, 10), false, 10},
+		{"first byte", data, changed(data, 0), false, 0},
+		{"middle chunk", data, changed(data, 5*cs+3), false, 5*cs + 3},
+		{"last chunk", data, changed(data, 10*cs+6), false, 10*cs + 6},
+		{"two differences", changed(data, 7*cs), changed(data, 2*cs+1), false, 2*cs + 1},
+		{"prefix", data[:4*cs+1], data, false, 4*cs + 1},
+		{"longer", data, data[:cs], false, cs},
+		{"empty and not", nil, data, false, 0},
+	} {
+		pathA, pathB := writeTmp(t, tt.a), writeTmp(t, tt.b)
+		equal, offset, err := r.Equal(pathA, pathB)
+		if err != nil || equal != tt.equal || offset != tt.offset {
+			t.Errorf("%s: Equal = %v, %d, %v, want %v, %d", tt.name, equal, offset, err, tt.equal, tt.offset)
+		}
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	for _, paths := range [][2]string{{missing, writeTmp(t, data)}, {writeTmp(t, data), missing}} {
+		_, _, err := r.Equal(paths[0], paths[1])
+		var re *ReadError
+		if !errors.As(err, &re) || re.Path != missing || !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Equal(%s, %s) = %v, want a ReadError for the missing file", paths[0], paths[1], err)
+		}
+	}
+}
@@ -6,128 +6,1323 @@ package filereader
 
 import (
 	"bufio"
-	"flag"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"os"
-	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 )
 
-// chunk size that each asynchronous thread will read
-// currently set to 1MB
+// default chunk size that each asynchronous thread will read
+// currently set to 1MB, see ReaderConfig.ChunkSize
 const asyncChunkSize = 1024 * 1024
 
-// buffer size of the synchronous scanner
+// default buffer size of the synchronous scanner
 // the size of each line is too big for the default buffer size of 64kB
-// increased buffer size to 512kB
+// increased buffer size to 512kB, see ReaderConfig.MaxLineSize
 const syncBufferSize = 512 * 1024
 
-var wg sync.WaitGroup
+// ErrEmptyPath is returned when the path to read is empty.
+var ErrEmptyPath = errors.New("filereader: path is empty")
 
-func main() {
-	// command line args
-	filename := flag.String("f", "", "path to file")
+// ErrIsDirectory is returned when the path to read is a directory.
+var ErrIsDirectory = errors.New("filereader: path is a directory, not a file")
 
-	flag.Parse()
+// ErrShortRead is returned when a chunk read returns fewer bytes
+// than asked for without any error.
+var ErrShortRead = errors.New("filereader: short read")
 
-	// throw fatal error if file path not passed in cmd line
-	if *filename == "" {
-		log.Fatal("filename is empty")
-		return
+// ErrChunkTimeout is returned when the read of a chunk takes longer
+// than ReaderConfig.ChunkTimeout.
+var ErrChunkTimeout = errors.New("filereader: chunk read timed out")
+
+// ErrFileTooLarge is returned when the data to read is larger than
+// ReaderConfig.MaxBytes.
+var ErrFileTooLarge = errors.New("filereader: file is too large")
+
+// ErrSymlinkNotAllowed is returned when the path to read is a symlink
+// and ReaderConfig.NoFollowSymlinks is set.
+var ErrSymlinkNotAllowed = errors.New("filereader: path is a symlink")
+
+// ErrInvalidSize is returned when the size to read from an io.ReaderAt
+// is negative.
+var ErrInvalidSize = errors.New("filereader: size must not be negative")
+
+// ErrFileChanged is returned by a Stable reader when the size of the
+// file changed while it was being read.
+var ErrFileChanged = errors.New("filereader: file size changed during the read")
+
+// the phases of a read a ReadError can come from
+const (
+	PhaseOpen     = "open"
+	PhaseStat     = "stat"
+	PhaseRead     = "read"
+	PhaseAssemble = "assemble"
+	PhaseWrite    = "write"
+)
+
+// ReadError is the error of a read which failed on the file, for callers
+// to see where: the path of the file, the phase of the read that failed,
+// one of the Phase constants, and the file offset of the failure. The
+// sentinel errors and the errors of the os package it wraps are still
+// found by errors.Is and errors.As.
+//
+// A file which can't be opened or is rejected, a directory, a symlink
+// with NoFollowSymlinks, fails in PhaseOpen, a file which can't be
+// stated in PhaseStat. A chunk which can't be read fails in PhaseRead,
+// an asynchronous read joins the ReadError of every failing chunk. The
+// contents not fitting the output, a buffer too short, MaxBytes, fail
+// in PhaseAssemble. WriteAsync, the one write, fails in PhaseWrite once
+// the file is created. An invalid config is not a ReadError.
+type ReadError struct {
+	// Path is the path of the file, empty when the read is not
+	// of a file, from an io.ReaderAt or io.Reader.
+	Path string
+
+	// Phase is the phase of the read which failed.
+	Phase string
+
+	// Offset is the offset in the file of the chunk or of the sequential
+	// read which failed, -1 when the failure is not at an offset.
+	Offset int64
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ReadError) Error() string {
+	msg := "filereader: " + e.Phase
+	if e.Path != "" {
+		msg += " " + e.Path
+	}
+	if e.Offset >= 0 {
+		msg += fmt.Sprintf(" at offset %d", e.Offset)
+	}
+	// the sentinel errors have the prefix already
+	return msg + ": " + strings.TrimPrefix(e.Err.Error(), "filereader: ")
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// readErr returns err as a ReadError of the read of the file at path,
+// or as it is when it is nil or a ReadError already.
+func readErr(path, phase string, offset int64, err error) error {
+	var re *ReadError
+	if err == nil || errors.As(err, &re) {
+		return err
 	}
+	return &ReadError{Path: path, Phase: phase, Offset: offset, Err: err}
+}
 
-	file, err := os.Open(*filename)
+// ReadAsync opens the file at path, reads it concurrently in chunks
+// and returns the contents reassembled in order. An empty file gives
+// an empty, non-nil slice.
+func ReadAsync(path string) ([]byte, error) {
+	return defaultReader.ReadAsync(path)
+}
+
+// ReadAsyncCtx is like ReadAsync but stops dispatching new chunks once
+// ctx is done and returns ctx.Err(). Chunks already being read are
+// allowed to finish before it returns.
+func ReadAsyncCtx(ctx context.Context, path string) ([]byte, error) {
+	return defaultReader.ReadAsyncCtx(ctx, path)
+}
+
+// ReadAsyncDeadline is like ReadAsyncCtx with a context done at
+// deadline: the read is stopped once deadline passes and fails with
+// context.DeadlineExceeded, for callers which never want to wait more
+// than so long whatever the number of chunks. ChunkTimeout bounds each
+// chunk, this bounds the whole read.
+func ReadAsyncDeadline(path string, deadline time.Time) ([]byte, error) {
+	return defaultReader.ReadAsyncDeadline(path, deadline)
+}
+
+// ReadAsyncStats is like ReadAsync but also returns the Stats of the read.
+func ReadAsyncStats(path string) ([]byte, Stats, error) {
+	return defaultReader.ReadAsyncStats(path)
+}
+
+// ReadAsyncMeta is like ReadAsync but also returns the metadata of
+// the file, from the stat done before reading it.
+func ReadAsyncMeta(path string) ([]byte, FileMeta, error) {
+	return defaultReader.ReadAsyncMeta(path)
+}
+
+// ReadAsync is the package level ReadAsync using r's config.
+func (r *Reader) ReadAsync(path string) ([]byte, error) {
+	return r.ReadAsyncCtx(context.Background(), path)
+}
+
+// ReadAsyncCtx is the package level ReadAsyncCtx using r's config.
+func (r *Reader) ReadAsyncCtx(ctx context.Context, path string) ([]byte, error) {
+	data, _, err := r.readAsync(ctx, path)
+	return data, err
+}
+
+// ReadAsyncDeadline is the package level ReadAsyncDeadline using r's config.
+func (r *Reader) ReadAsyncDeadline(path string, deadline time.Time) ([]byte, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	return r.ReadAsyncCtx(ctx, path)
+}
+
+// ReadAsyncStats is the package level ReadAsyncStats using r's config.
+func (r *Reader) ReadAsyncStats(path string) ([]byte, Stats, error) {
+	return r.readAsync(context.Background(), path)
+}
+
+// ReadAsyncMeta is the package level ReadAsyncMeta using r's config.
+func (r *Reader) ReadAsyncMeta(path string) ([]byte, FileMeta, error) {
+	data, _, fileStats, err := r.readAsyncFile(context.Background(), path)
 	if err != nil {
-		log.Fatal("cannot able to read the file", err)
-		return
+		return nil, FileMeta{}, err
+	}
+	return data, newFileMeta(fileStats), nil
+}
+
+func (r *Reader) readAsync(ctx context.Context, path string) ([]byte, Stats, error) {
+	data, stats, _, err := r.readAsyncFile(ctx, path)
+	return data, stats, err
+}
+
+// readAsyncFile is readAsync also returning the FileInfo of the file.
+func (r *Reader) readAsyncFile(ctx context.Context, path string) ([]byte, Stats, os.FileInfo, error) {
+	startTime := time.Now()
+
+	if err := r.validate(); err != nil {
+		return nil, Stats{}, nil, err
+	}
+
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return nil, Stats{}, nil, err
 	}
 	defer file.Close()
 
+	data, stats, err := r.asyncReadFile(ctx, file, fileStats, nil)
+	stats.Duration = time.Since(startTime)
+	if err == nil {
+		r.cfg.Metrics.ObserveRead(stats.BytesRead, stats.Duration)
+	}
+	return data, stats, fileStats, err
+}
+
+// ReadSync opens the file at path and scans it line by line
+// with a single bufio.Scanner. The lines are discarded, it only
+// exists as the baseline for the asynchronous read.
+func ReadSync(path string) error {
+	return defaultReader.ReadSync(path)
+}
+
+// ReadSyncStats is like ReadSync but also returns the Stats of the read,
+// comparable to the ones of ReadAsyncStats.
+func ReadSyncStats(path string) (Stats, error) {
+	return defaultReader.ReadSyncStats(path)
+}
+
+// ReadSyncFrom scans src line by line like ReadSync, for sources that
+// can only be read sequentially such as os.Stdin or a pipe.
+func ReadSyncFrom(src io.Reader) (Stats, error) {
+	return defaultReader.ReadSyncFrom(src)
+}
+
+// ReadSyncLines is like ReadSyncStats but calls fn with every line and
+// its 1-based number instead of discarding them. line is only valid
+// until fn returns. The first error returned by fn stops the read and
+// is returned.
+func ReadSyncLines(path string, fn func(number int64, line []byte) error) (Stats, error) {
+	return defaultReader.ReadSyncLines(path, fn)
+}
+
+// ReadSyncLinesFrom is ReadSyncLines for sources that can only be read
+// sequentially, like ReadSyncFrom.
+func ReadSyncLinesFrom(src io.Reader, fn func(number int64, line []byte) error) (Stats, error) {
+	return defaultReader.ReadSyncLinesFrom(src, fn)
+}
+
+// ReadSync is the package level ReadSync using r's config.
+func (r *Reader) ReadSync(path string) error {
+	_, err := r.ReadSyncStats(path)
+	return err
+}
+
+// ReadSyncStats is the package level ReadSyncStats using r's config.
+func (r *Reader) ReadSyncStats(path string) (Stats, error) {
+	return r.ReadSyncLines(path, nil)
+}
+
+// ReadSyncFrom is the package level ReadSyncFrom using r's config.
+func (r *Reader) ReadSyncFrom(src io.Reader) (Stats, error) {
+	return r.ReadSyncLinesFrom(src, nil)
+}
+
+// ReadSyncLines is the package level ReadSyncLines using r's config.
+func (r *Reader) ReadSyncLines(path string, fn func(number int64, line []byte) error) (Stats, error) {
 	startTime := time.Now()
-	syncReadFile(file)
-	endTime := time.Now()
-	log.Println("time taken for syncronous file reading", endTime.Sub(startTime))
 
-	startTime = time.Now()
-	asyncReadFile(file)
-	endTime = time.Now()
-	log.Println("time taken for asyncronous file reading", endTime.Sub(startTime))
+	src, err := r.openSequential(path)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer src.Close()
+
+	stats, _, err := r.syncReadFile(path, src, fn)
+	stats.Duration = time.Since(startTime)
+	if err == nil {
+		r.cfg.Metrics.ObserveRead(stats.BytesRead, stats.Duration)
+	}
+	return stats, err
 }
 
-func asyncReadFile(file *os.File) {
-	fileStats, e := file.Stat()
-	if e != nil {
-		log.Println(e)
-		return
+// ReadSyncLinesFrom is the package level ReadSyncLinesFrom using r's config.
+func (r *Reader) ReadSyncLinesFrom(src io.Reader, fn func(number int64, line []byte) error) (Stats, error) {
+	startTime := time.Now()
+
+	if err := r.validate(); err != nil {
+		return Stats{}, err
 	}
 
-	filesize := int(fileStats.Size())
+	stats, _, err := r.syncReadFile("", src, fn)
+	stats.Duration = time.Since(startTime)
+	if err == nil {
+		r.cfg.Metrics.ObserveRead(stats.BytesRead, stats.Duration)
+	}
+	return stats, err
+}
+
+// ReadAsyncFrom reads the first size bytes of src concurrently in chunks
+// and returns them reassembled in order. src can be anything supporting
+// positioned reads, a bytes.Reader, a memory mapped region, a section
+// of a bigger file... A negative size fails with ErrInvalidSize.
+func ReadAsyncFrom(src io.ReaderAt, size int64) ([]byte, error) {
+	return defaultReader.ReadAsyncFrom(src, size)
+}
 
-	// Number of go routines we need to spawn.
-	concurrency := filesize / asyncChunkSize
-	// check for any left over bytes. Add one more go routine if required.
-	if filesize%asyncChunkSize != 0 {
-		concurrency++
+// ReadAsyncFrom is the package level ReadAsyncFrom using r's config.
+func (r *Reader) ReadAsyncFrom(src io.ReaderAt, size int64) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
 	}
 
-	// create an array of same size as number of goroutines
-	// each element of the array indicates the offset at which
-	// that goroutine should start reading the file
-	chunkOffset := make([]int64, concurrency)
+	data, _, err := r.asyncRead(context.Background(), "", src, 0, size, nil)
+	return data, err
+}
 
-	// Offsets depend on the index.
-	// Second go routine should start at 100, for example, given a
-	// buffer size of 100.
-	for i := 0; i < concurrency; i++ {
-		chunkOffset[i] = int64(asyncChunkSize * i)
+// ReadAsyncInto is like ReadAsync but reads the file into buf instead
+// of allocating the output, so buffers can be recycled across reads.
+// It returns the number of bytes read, the file contents being
+// buf[:n]. A buffer shorter than the file fails with io.ErrShortBuffer
+// before anything is read, its contents are undefined after any error.
+func ReadAsyncInto(path string, buf []byte) (int, error) {
+	return defaultReader.ReadAsyncInto(path, buf)
+}
+
+// ReadAsyncInto is the package level ReadAsyncInto using r's config.
+func (r *Reader) ReadAsyncInto(path string, buf []byte) (int, error) {
+	if err := r.validate(); err != nil {
+		return 0, err
 	}
 
-	// get number of cpu in the current machine
-	gochannel := make(chan int64, runtime.NumCPU())
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// a nil buf would mean allocating one
+	if buf == nil {
+		buf = []byte{}
+	}
+
+	data, _, err := r.asyncReadFile(context.Background(), file, fileStats, buf)
+	return len(data), err
+}
+
+// ReadAsyncBuffer is like ReadAsync but returns the contents in a
+// bytes.Buffer, for callers parsing them from one. The buffer takes the
+// output of the read over as its backing array, the one allocation of
+// the size of the file, so nothing is grown or copied.
+func ReadAsyncBuffer(path string) (*bytes.Buffer, error) {
+	return defaultReader.ReadAsyncBuffer(path)
+}
+
+// ReadAsyncBuffer is the package level ReadAsyncBuffer using r's config.
+func (r *Reader) ReadAsyncBuffer(path string) (*bytes.Buffer, error) {
+	data, err := r.ReadAsync(path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// ReadAsyncFile is like ReadAsync but reads f, a file the caller opened,
+// and doesn't close it. Every read goes through ReadAt, which leaves the
+// file offset alone, so f can be read again, even by concurrent calls,
+// each read being independent of the others.
+func ReadAsyncFile(f *os.File) ([]byte, error) {
+	return defaultReader.ReadAsyncFile(f)
+}
+
+// ReadAsyncFile is the package level ReadAsyncFile using r's config.
+func (r *Reader) ReadAsyncFile(f *os.File) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	fileStats, err := f.Stat()
+	if err != nil {
+		return nil, &ReadError{Path: f.Name(), Phase: PhaseStat, Offset: -1, Err: err}
+	}
+	if fileStats.IsDir() {
+		return nil, &ReadError{Path: f.Name(), Phase: PhaseOpen, Offset: -1, Err: ErrIsDirectory}
+	}
+
+	data, _, err := r.asyncReadFile(context.Background(), f, fileStats, nil)
+	return data, err
+}
+
+// asyncReadFile reads the whole file concurrently and returns its contents
+// reassembled in order, into buf when it is not nil.
+func (r *Reader) asyncReadFile(ctx context.Context, file *os.File, fileStats os.FileInfo, buf []byte) ([]byte, Stats, error) {
+	// pipes, devices and sockets have no size, and often no ReadAt,
+	// read them from start to end instead of splitting nothing
+	if !fileStats.Mode().IsRegular() {
+		r.cfg.Logger.Printf("filereader: %s is not a regular file, reading it sequentially", file.Name())
+		return r.readStreamAll(file.Name(), file, buf)
+	}
+
+	gz, err := r.gzipReader(file)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	if gz != nil {
+		defer gz.Close()
+
+		// the size of the decompressed data is unknown and the stream
+		// can only be read from start to end, so fall back to a
+		// plain sequential read.
+		return r.readStreamAll(file.Name(), gz, buf)
+	}
+
+	src, strategy, release := r.source(file, fileStats.Size())
+	defer release()
+
+	skip, err := r.skipBOM(file)
+	if err != nil {
+		return nil, Stats{}, readErr(file.Name(), PhaseRead, 0, err)
+	}
+	size := fileStats.Size() - skip
+	if skip > 0 {
+		src = io.NewSectionReader(src, skip, size)
+	}
+
+	data, stats, err := r.asyncRead(ctx, file.Name(), src, skip, size, buf)
+	if stats.Strategy == StrategyReadAt {
+		stats.Strategy = strategy
+	}
+	if err != nil {
+		return nil, stats, err
+	}
+	if err := r.checkStable(file, fileStats); err != nil {
+		return nil, stats, err
+	}
+	return data, stats, nil
+}
+
+// checkStable stats file again when r is configured as Stable and
+// returns ErrFileChanged if its size is not the one of fileStats.
+func (r *Reader) checkStable(file *os.File, fileStats os.FileInfo) error {
+	if !r.cfg.Stable {
+		return nil
+	}
 
-	// run as many jobs as the number of cpus available
-	// once a job is completed, initiate the next job
-	// The number of jobs running at any one time should
-	// be same as number of cpu.
+	now, err := file.Stat()
+	if err != nil {
+		return readErr(file.Name(), PhaseStat, -1, err)
+	}
+	if now.Size() != fileStats.Size() {
+		err := fmt.Errorf("%w: it was %d bytes and is now %d bytes", ErrFileChanged, fileStats.Size(), now.Size())
+		return readErr(file.Name(), PhaseStat, -1, err)
+	}
+	return nil
+}
+
+// openFile opens the file at path for reading and stats it.
+// Directories are rejected with ErrIsDirectory before any read,
+// as their size is meaningless and ReadAt fails on them.
+//
+// Errors from the os package are wrapped, so callers can still tell
+// a missing file from a forbidden one with errors.Is(err, os.ErrNotExist)
+// or errors.Is(err, os.ErrPermission).
+//
+// With NoFollowSymlinks a symlink is rejected with ErrSymlinkNotAllowed.
+func (r *Reader) openFile(path string) (*os.File, os.FileInfo, error) {
+	if path == "" {
+		return nil, nil, &ReadError{Phase: PhaseOpen, Offset: -1, Err: ErrEmptyPath}
+	}
+
+	var linkStats os.FileInfo
+	if r.cfg.NoFollowSymlinks {
+		var err error
+		linkStats, err = os.Lstat(longPath(path))
+		if err != nil {
+			return nil, nil, &ReadError{Path: path, Phase: PhaseStat, Offset: -1, Err: err}
+		}
+		if linkStats.Mode()&os.ModeSymlink != 0 {
+			return nil, nil, &ReadError{Path: path, Phase: PhaseOpen, Offset: -1, Err: ErrSymlinkNotAllowed}
+		}
+	}
+
+	file, err := os.Open(longPath(path))
+	if err != nil {
+		return nil, nil, &ReadError{Path: path, Phase: PhaseOpen, Offset: -1, Err: err}
+	}
+
+	fileStats, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, &ReadError{Path: path, Phase: PhaseStat, Offset: -1, Err: err}
+	}
+
+	// the path may have been swapped for a symlink since the Lstat
+	if linkStats != nil && !os.SameFile(linkStats, fileStats) {
+		file.Close()
+		return nil, nil, &ReadError{Path: path, Phase: PhaseOpen, Offset: -1, Err: ErrSymlinkNotAllowed}
+	}
+
+	if fileStats.IsDir() {
+		file.Close()
+		return nil, nil, &ReadError{Path: path, Phase: PhaseOpen, Offset: -1, Err: ErrIsDirectory}
+	}
+
+	return file, fileStats, nil
+}
+
+// readStreamAll reads src, which can only be read from start to end,
+// sequentially into buf, into a new slice when buf is nil. path is the
+// path of the file src comes from, for the errors.
+func (r *Reader) readStreamAll(path string, src io.Reader, buf []byte) ([]byte, Stats, error) {
+	src, err := r.stripBOMReader(src)
+	if err != nil {
+		return nil, Stats{}, readErr(path, PhaseRead, 0, err)
+	}
+
+	var data []byte
+	if buf != nil {
+		data, err = readInto(src, buf)
+	} else {
+		data, err = r.readAllLimited(src)
+	}
+	stats := Stats{
+		BytesRead:      int64(len(data)),
+		ChunkCount:     1,
+		GoroutinesUsed: 1,
+		Strategy:       StrategySequential,
+	}
+	if errors.Is(err, ErrFileTooLarge) || errors.Is(err, io.ErrShortBuffer) {
+		return nil, stats, readErr(path, PhaseAssemble, -1, err)
+	}
+	if err != nil {
+		return nil, stats, readErr(path, PhaseRead, -1, err)
+	}
+	if r.cfg.OnChunk != nil && len(data) > 0 {
+		r.cfg.OnChunk(0, data)
+	}
+	return data, stats, nil
+}
+
+// checkSize returns ErrFileTooLarge when size bytes are more than
+// r's MaxBytes, or than a slice can hold on this platform.
+func (r *Reader) checkSize(size int64) error {
+	if (r.cfg.MaxBytes > 0 && size > r.cfg.MaxBytes) || int64(int(size)) != size {
+		return fmt.Errorf("%w: %d bytes", ErrFileTooLarge, size)
+	}
+	return nil
+}
+
+// readAllLimited reads src to the end like io.ReadAll, but fails with
+// ErrFileTooLarge as soon as it has read more than r's MaxBytes.
+func (r *Reader) readAllLimited(src io.Reader) ([]byte, error) {
+	if r.cfg.MaxBytes == 0 {
+		return io.ReadAll(src)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(src, r.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > r.cfg.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrFileTooLarge, r.cfg.MaxBytes)
+	}
+	return data, nil
+}
+
+// readInto reads src to the end into buf and returns the part of buf
+// it filled, or io.ErrShortBuffer if src has more than len(buf) bytes.
+func readInto(src io.Reader, buf []byte) ([]byte, error) {
+	n, err := io.ReadFull(src, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return buf[:n], nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// buf is full, src must be over
+	var more [1]byte
+	if m, err := io.ReadFull(src, more[:]); m > 0 {
+		return nil, fmt.Errorf("%w: more than %d bytes to read", io.ErrShortBuffer, len(buf))
+	} else if err != io.EOF {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// asyncRead reads size bytes of src concurrently and returns them
+// reassembled in order, into buf when it is not nil. path and base are
+// the path of the file src comes from, for the errors, and the offset
+// of src in it, the chunks are aligned relative to the file.
+func (r *Reader) asyncRead(ctx context.Context, path string, src io.ReaderAt, base, size int64, buf []byte) ([]byte, Stats, error) {
+	// output buffer holding the whole file.
+	// each chunk is read straight into its own region
+	// [offset, offset+length) so no locking is needed.
+	// for an empty file it is an empty but non-nil slice,
+	// and there is no chunk to read at all.
+	if size < 0 {
+		err := fmt.Errorf("%w: %d bytes", ErrInvalidSize, size)
+		return nil, Stats{}, readErr(path, PhaseAssemble, -1, err)
+	}
+	var data []byte
+	if buf != nil {
+		if int64(len(buf)) < size {
+			err := fmt.Errorf("%w: %d bytes to read into %d", io.ErrShortBuffer, size, len(buf))
+			return nil, Stats{}, readErr(path, PhaseAssemble, -1, err)
+		}
+		data = buf[:size]
+	} else {
+		if err := r.checkSize(size); err != nil {
+			return nil, Stats{}, readErr(path, PhaseAssemble, -1, err)
+		}
+		data = make([]byte, size)
+	}
+
+	// for small files spawning goroutines costs more than it saves,
+	// read them sequentially instead
+	sequential := r.cfg.Strategy == StrategySequential
+	if !sequential && size < r.cfg.SyncThreshold {
+		r.cfg.Logger.Printf("filereader: %d bytes is below the sync threshold of %d bytes, reading sequentially", size, r.cfg.SyncThreshold)
+		sequential = true
+	}
+	if sequential {
+
+		stats := Stats{GoroutinesUsed: 1, Strategy: StrategySequential}
+		if size > 0 {
+			stats.ChunkCount = 1
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, stats, err
+		}
+
+		n, err := io.ReadFull(io.NewSectionReader(src, 0, size), data)
+		stats.BytesRead = int64(n)
+		if err != nil {
+			return nil, stats, readErr(path, PhaseRead, base+int64(n), err)
+		}
+		if r.cfg.OnChunk != nil && size > 0 {
+			r.cfg.OnChunk(0, data)
+		}
+		if r.cfg.Progress != nil {
+			r.cfg.Progress(size, size, 0)
+		}
+		return data, stats, nil
+	}
+
+	var order *chunkOrder
+	if r.cfg.OnChunk != nil {
+		order = newChunkOrder(r.cfg.OnChunk, data)
+	}
+
+	hooks := chunkHooks{
+		buffer: func(offset, length int64) []byte {
+			return data[offset : offset+length]
+		},
+		base: base,
+		path: path,
+	}
+	if order != nil {
+		hooks.handle = func(offset int64, chunk []byte) error {
+			order.complete(offset, int64(len(chunk)))
+			return nil
+		}
+	}
+
+	if r.cfg.Strategy == StrategyAdaptive {
+		stats, err := r.readAdaptive(ctx, path, src, base, size, data, order)
+		if err != nil {
+			return nil, stats, err
+		}
+		return data, stats, nil
+	}
+
+	stats, err := r.readChunks(ctx, src, size, hooks)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	return data, stats, nil
+}
+
+// chunkOrder hands the chunks of a read of data to ReaderConfig.OnChunk
+// in offset order, while they complete in any order.
+type chunkOrder struct {
+	fn   func(offset int64, data []byte)
+	data []byte
+
+	mu   sync.Mutex
+	next int64
+	// length of the chunks read past next, by offset
+	done map[int64]int64
+}
+
+func newChunkOrder(fn func(offset int64, data []byte), data []byte) *chunkOrder {
+	return &chunkOrder{fn: fn, data: data, done: make(map[int64]int64)}
+}
+
+// complete records the length bytes at offset as read, and calls fn
+// with every chunk from next on which is read. The lock serializes the
+// calls, a worker completing the chunk at next calls fn for the chunks
+// which were waiting for it too.
+func (o *chunkOrder) complete(offset, length int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.done[offset] = length
+	for length, ok := o.done[o.next]; ok; length, ok = o.done[o.next] {
+		delete(o.done, o.next)
+		o.fn(o.next, o.data[o.next:o.next+length])
+		o.next += length
+	}
+}
+
+// chunkHooks customises what readChunks does with every chunk.
+type chunkHooks struct {
+	// buffer returns the slice the chunk at offset is read into.
+	buffer func(offset, length int64) []byte
+
+	// handle, when not nil, is called by the worker with every chunk
+	// once it is read. An error stops the read.
+	handle func(offset int64, data []byte) error
+
+	// window, when not nil, is used as a semaphore: a slot is taken
+	// before every chunk is dispatched, in chunk order, and it is up
+	// to the caller to release it. This bounds how far the workers can
+	// get ahead of a slow consumer.
+	window chan struct{}
+
+	// overlap is the number of bytes past its end every chunk also
+	// reads (up to the end of file), so data straddling two chunks
+	// is seen whole by the first one.
+	overlap int64
+
+	// base is the offset of the source in its file, ReaderConfig.AlignTo
+	// aligns the chunks on the file offsets rather than the source ones.
+	base int64
+
+	// path is the path of the file, for the ReadError of the chunks.
+	path string
+}
+
+// chunkRead is the state shared by the workers of one asynchronous read.
+type chunkRead struct {
+	chunkHooks
+
+	ctx         context.Context
+	logger      Logger
+	metrics     Metrics
+	limiter     chan struct{}
+	rate        *rate.Limiter
+	src         io.ReaderAt
+	filesize    int64
+	grid        chunkGrid
+	maxAttempts int
+	backoff     time.Duration
+	timeout     time.Duration
+
+	// time taken by the read of each chunk, indexed by
+	// chunk, nil unless ChunkTiming is set.
+	chunkDurations []time.Duration
+
+	// total bytes read by the workers, updated atomically
+	bytesRead int64
+
+	// progress reporting, guarded by progressMu so the
+	// callback is never called concurrently
+	progress      func(bytesDone, total int64, eta time.Duration)
+	progressMu    sync.Mutex
+	eta           *etaEstimator
+	progressEvery int
+	chunksDone    int
+	bytesDone     int64
+}
+
+// chunkError is the error of the chunk at index of a read.
+type chunkError struct {
+	index int
+	err   error
+}
+
+// readChunks splits the first size bytes of src into chunks of
+// r's chunk size and reads them with at most r's concurrency goroutines
+// at the same time. Every chunk is
+// read into the slice returned by hooks.buffer and then passed to
+// hooks.handle.
+//
+// The first failing chunk cancels the remaining ones. The errors of
+// every chunk which failed, with their offset in the file and chunk
+// index, are returned joined in chunk order, otherwise ctx.Err() if
+// ctx was cancelled. Whatever the
+// outcome, every goroutine started for the read has exited by the time
+// readChunks returns, but for the ReadAt calls abandoned by the chunk
+// timeout. The returned Stats have everything but the Duration filled in.
+func (r *Reader) readChunks(ctx context.Context, src io.ReaderAt, size int64, hooks chunkHooks) (Stats, error) {
+	grid := r.chunkGrid(hooks.base, size)
+	chunkCount := grid.count
+
+	// the group cancels this context on the first error
+	// so no more chunks are dispatched.
+	g, chunkCtx := errgroup.WithContext(ctx)
+
+	cr := &chunkRead{
+		chunkHooks:  hooks,
+		ctx:         chunkCtx,
+		logger:      r.cfg.Logger,
+		metrics:     r.cfg.Metrics,
+		limiter:     r.limiter,
+		rate:        r.rate,
+		src:         src,
+		filesize:    size,
+		grid:        grid,
+		maxAttempts: r.cfg.MaxAttempts,
+		backoff:     r.cfg.RetryBackoff,
+		timeout:     r.cfg.ChunkTimeout,
+		progress:    r.cfg.Progress,
+	}
+	if r.cfg.ChunkTiming {
+		cr.chunkDurations = make([]time.Duration, chunkCount)
+	}
+
+	// report progress every progressEvery chunks,
+	// about a hundred times per read
+	if cr.progress != nil {
+		cr.eta = newETAEstimator()
+	}
+	cr.progressEvery = chunkCount / 100
+	if cr.progressEvery < 1 {
+		cr.progressEvery = 1
+	}
+
+	// there is no point in running more workers than chunks.
+	workers := r.concurrency()
+	if workers > chunkCount {
+		workers = chunkCount
+	}
+
+	// every chunk is read by its own goroutine of the group, at most
+	// as many at the same time as the configured concurrency: g.Go
+	// suspends the for loop below till one of them is done.
 	//
-	// This is achieved using a integer gochannel of size = #cpu
-	// Once a async read job starts, add a int to the goChannel.
-	// When the go channel is full, the for loop suspends till a
-	// space becomes available in the channel.
-	// When the job is completed the async function ejects a element
-	// from the channel, leaving a empty space in the channel for
-	// the next job to start.
-	for i := 0; i < concurrency; i++ {
-		wg.Add(1)
-		gochannel <- 1
-		go readChunk(gochannel, file, chunkOffset, i)
+	// the group is local to every call, so concurrent reads
+	// never share (and corrupt) each other's state.
+	g.SetLimit(workers)
+
+	// every chunk which failed, not only the first one, so the caller
+	// sees all the broken parts of the file
+	var errMu sync.Mutex
+	var chunkErrs []chunkError
+
+	// Waiting for a window slot also watches the context, and
+	// once it is cancelled no new chunk is started.
+	// the in order reads consume the chunks from the first one and
+	// only have a window of them in memory, they can't start at the end
+	reverse := r.cfg.ReverseOrder && cr.window == nil
+
+dispatch:
+	for n := 0; n < chunkCount; n++ {
+		i := n
+		if reverse {
+			i = chunkCount - 1 - n
+		}
+		if cr.window != nil {
+			select {
+			case <-chunkCtx.Done():
+				break dispatch
+			case cr.window <- struct{}{}:
+			}
+		}
+		if chunkCtx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			err := cr.readChunk(i)
+			if err == nil {
+				return nil
+			}
+			// the chunks stopped by the cancellation only repeat it
+			if ctxErr := chunkCtx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+				return err
+			}
+
+			err = &ReadError{Path: cr.path, Phase: PhaseRead, Offset: cr.base + cr.grid.offset(i), Err: fmt.Errorf("chunk %d: %w", i, err)}
+			cr.logger.Printf("%v", err)
+			cr.metrics.IncChunkError()
+			errMu.Lock()
+			chunkErrs = append(chunkErrs, chunkError{index: i, err: err})
+			errMu.Unlock()
+			return err
+		})
+	}
+
+	// always wait for the chunks already started, even when cancelled,
+	// so no goroutine outlives this call.
+	err := g.Wait()
+	if len(chunkErrs) > 0 {
+		sort.Slice(chunkErrs, func(a, b int) bool { return chunkErrs[a].index < chunkErrs[b].index })
+		errs := make([]error, len(chunkErrs))
+		for k, c := range chunkErrs {
+			errs[k] = c.err
+		}
+		err = errors.Join(errs...)
+	}
+
+	stats := Stats{
+		BytesRead:      atomic.LoadInt64(&cr.bytesRead),
+		ChunkCount:     chunkCount,
+		GoroutinesUsed: workers,
+		Strategy:       StrategyReadAt,
+		ChunkSize:      r.cfg.ChunkSize,
+		ChunkDurations: cr.chunkDurations,
+	}
+
+	if err != nil {
+		return stats, err
+	}
+	return stats, ctx.Err()
+}
+
+// chunkGrid is the split of a read into chunks. The offsets are
+// computed from the chunk index when needed rather than stored, a huge
+// file has a lot of chunks.
+type chunkGrid struct {
+	// sizes and offsets stay int64 all along, an int would overflow
+	// past 2GB on 32 bit platforms.
+	firstChunk int64
+	chunkSize  int64
+	count      int
+}
+
+// chunkGrid splits size bytes starting at base in the file into
+// chunks of r's chunk size.
+func (r *Reader) chunkGrid(base, size int64) chunkGrid {
+	chunkSize := r.cfg.ChunkSize
+
+	// with AlignTo the first chunk only goes up to the first aligned
+	// offset of the file, so every chunk after it starts aligned.
+	firstChunk := chunkSize
+	if align := r.cfg.AlignTo; align > 0 && base%align != 0 {
+		firstChunk = align - base%align
+	}
+
+	// Number of chunks we need to read: the first one,
+	// then the chunks of whatever is left after it.
+	count := 0
+	if size > 0 {
+		count = 1 + ChunksFor(size-firstChunk, chunkSize)
+	}
+	return chunkGrid{firstChunk: firstChunk, chunkSize: chunkSize, count: count}
+}
+
+// offset returns the offset, relative to the base of the grid, at which
+// chunk i starts. Second chunk should start at 100, for example, given
+// a buffer size of 100.
+func (g chunkGrid) offset(i int) int64 {
+	if i == 0 {
+		return 0
+	}
+	return g.firstChunk + g.chunkSize*int64(i-1)
+}
+
+// chunkOffsets returns the offset, relative to base, at which every
+// chunk of r's split of size bytes starting at base starts.
+func (r *Reader) chunkOffsets(base, size int64) []int64 {
+	grid := r.chunkGrid(base, size)
+	chunkOffset := make([]int64, grid.count)
+	for i := range chunkOffset {
+		chunkOffset[i] = grid.offset(i)
+	}
+	return chunkOffset
+}
+
+func (cr *chunkRead) readChunk(i int) error {
+	// the read may have been cancelled while this chunk was waiting
+	// to be scheduled, skip it in that case. The cancellation itself
+	// is reported by readChunks.
+	if cr.ctx.Err() != nil {
+		return nil
+	}
+
+	offset := cr.grid.offset(i)
+	length := cr.length(i)
+	if cr.overlap > 0 {
+		length = chunkLength(cr.filesize, length+cr.overlap, offset)
+	}
+
+	// wait for the bytes of the chunk before taking a limiter
+	// slot, which other reads could use meanwhile
+	if cr.rate != nil {
+		if err := cr.throttle(length); err != nil {
+			return err
+		}
+	}
+
+	// read exactly length bytes from the source starting at offset
+	// directly into this chunk's buffer
+	buf := cr.buffer(offset, length)
+	start := time.Now()
+	err := cr.readAt(buf, offset)
+	if cr.chunkDurations != nil {
+		cr.chunkDurations[i] = time.Since(start)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cr.handle != nil {
+		if err := cr.handle(offset, buf); err != nil {
+			return err
+		}
+	}
+
+	cr.reportProgress(length)
+	return nil
+}
+
+// length returns the number of bytes of chunk i, without the overlap.
+// Only the first and the last chunks may be shorter than the chunk size.
+func (cr *chunkRead) length(i int) int64 {
+	end := cr.filesize
+	if i+1 < cr.grid.count {
+		end = cr.grid.offset(i + 1)
 	}
+	return end - cr.grid.offset(i)
+}
+
+// readAt fills buf from offset, retrying failed reads up to
+// maxAttempts times with an exponential backoff in between.
+func (cr *chunkRead) readAt(buf []byte, offset int64) error {
+	backoff := cr.backoff
+	for attempt := 1; ; attempt++ {
+		n, err := cr.readAtOnce(buf, offset)
+		if ctxErr := cr.ctx.Err(); ctxErr != nil {
+			// buf may not have been read, the chunk must not reach
+			// handle. readChunks reports the cancellation once
+			return ctxErr
+		}
 
-	wg.Wait()
+		// ReadAt may report io.EOF along with a full read of the final
+		// chunk, any other read must fill the whole buffer. A short
+		// read without error breaks the io.ReaderAt contract, but
+		// would leave a hole in the chunk, so it fails too.
+		if err == io.EOF && n < len(buf) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil && n < len(buf) {
+			err = fmt.Errorf("%w: %d of %d bytes", ErrShortRead, n, len(buf))
+		}
+		if err == nil || err == io.EOF {
+			atomic.AddInt64(&cr.bytesRead, int64(n))
+			return nil
+		}
+
+		// a truncated file won't grow back, only retry other errors
+		if err == io.ErrUnexpectedEOF || attempt >= cr.maxAttempts {
+			return fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+
+		cr.logger.Printf("filereader: read at offset %d failed, retrying in %v: %v", offset, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-cr.ctx.Done():
+			// don't leave the timer behind when the read is over
+			timer.Stop()
+			return cr.ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
 }
 
-func readChunk(gochannel chan int64, file *os.File, chunkOffset []int64, i int) {
-	// read certain bytes from the file starting at offset
-	_, _err := file.ReadAt(make([]byte, asyncChunkSize), chunkOffset[i])
+// throttle waits for n bytes in the token bucket of MaxBytesPerSec,
+// in pieces as a chunk can be bigger than the bucket.
+func (cr *chunkRead) throttle(n int64) error {
+	burst := int64(cr.rate.Burst())
+	for n > 0 {
+		piece := n
+		if piece > burst {
+			piece = burst
+		}
+		if err := cr.rate.WaitN(cr.ctx, int(piece)); err != nil {
+			// WaitN fails right away when the wait would go past the
+			// deadline of the read, which is then bound to expire: wait
+			// for it, and fail the chunk with the cancellation, which
+			// readChunks reports once
+			if _, ok := cr.ctx.Deadline(); ok {
+				<-cr.ctx.Done()
+			}
+			if ctxErr := cr.ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+		n -= piece
+	}
+	return nil
+}
 
-	if _err != nil && _err != io.EOF {
-		log.Println(_err)
+// readAtOnce is a single ReadAt of buf at offset, abandoned with
+// ErrChunkTimeout when it takes longer than the chunk timeout.
+func (cr *chunkRead) readAtOnce(buf []byte, offset int64) (int, error) {
+	// reads sharing a limiter also share its slots, hold one for the
+	// time of the ReadAt, and of an abandoned one until it returns
+	release := func() {}
+	if cr.limiter != nil {
+		select {
+		case <-cr.ctx.Done():
+			return 0, cr.ctx.Err()
+		case cr.limiter <- struct{}{}:
+		}
+		release = func() { <-cr.limiter }
+	}
+
+	if cr.timeout <= 0 {
+		defer release()
+		return cr.src.ReadAt(buf, offset)
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+
+	// an abandoned ReadAt can't be stopped and may write its buffer
+	// any time later, so it gets its own, copied over when in time
+	private := make([]byte, len(buf))
+	done := make(chan result, 1)
+	go func() {
+		n, err := cr.src.ReadAt(private, offset)
+		release()
+		done <- result{n, err}
+	}()
+
+	timer := time.NewTimer(cr.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		copy(buf, private[:res.n])
+		return res.n, res.err
+	case <-timer.C:
+		return 0, fmt.Errorf("%w after %v", ErrChunkTimeout, cr.timeout)
+	case <-cr.ctx.Done():
+		return 0, cr.ctx.Err()
+	}
+}
+
+// reportProgress records a chunk of length bytes as done
+// and calls the progress callback when it is time to.
+func (cr *chunkRead) reportProgress(length int64) {
+	if cr.progress == nil {
 		return
 	}
 
-	<-gochannel
-	wg.Done()
+	cr.progressMu.Lock()
+	defer cr.progressMu.Unlock()
+
+	cr.chunksDone++
+	cr.bytesDone += length
+	if cr.chunksDone%cr.progressEvery == 0 || cr.bytesDone == cr.filesize {
+		cr.progress(cr.bytesDone, cr.filesize, cr.eta.estimate(cr.bytesDone, cr.filesize))
+	}
 }
 
-func syncReadFile(file *os.File) {
-	scanner := bufio.NewScanner(file)
+// weight of the latest throughput sample in the smoothed throughput
+const etaSmoothing = 0.3
 
-	// increase buffer size of scanner
-	buf := make([]byte, syncBufferSize)
-	scanner.Buffer(buf, syncBufferSize)
+// etaEstimator estimates the time left of a read from its throughput,
+// an exponential moving average of the throughput between two progress
+// reports. It is not safe for concurrent use.
+type etaEstimator struct {
+	last      time.Time
+	lastBytes int64
+	rate      float64 // bytes per second, 0 until measured
+}
+
+func newETAEstimator() *etaEstimator {
+	return &etaEstimator{last: time.Now()}
+}
+
+// estimate records that bytesDone bytes were read and returns the
+// time left to read total bytes.
+func (e *etaEstimator) estimate(bytesDone, total int64) time.Duration {
+	now := time.Now()
+	if elapsed := now.Sub(e.last).Seconds(); elapsed > 0 && bytesDone > e.lastBytes {
+		sample := float64(bytesDone-e.lastBytes) / elapsed
+		if e.rate == 0 {
+			e.rate = sample
+		} else {
+			e.rate = etaSmoothing*sample + (1-etaSmoothing)*e.rate
+		}
+		e.last, e.lastBytes = now, bytesDone
+	}
+
+	if bytesDone >= total || e.rate == 0 {
+		return 0
+	}
+	return time.Duration(float64(total-bytesDone) / e.rate * float64(time.Second))
+}
+
+// chunkLength returns the number of bytes the chunk starting at offset
+// should read. Every chunk is chunkSize long except the last one,
+// which only covers the filesize - offset bytes left in the file, so
+// we never ask ReadAt for bytes past the end of file.
+func chunkLength(filesize, chunkSize, offset int64) int64 {
+	if remaining := filesize - offset; remaining < chunkSize {
+		return remaining
+	}
+	return chunkSize
+}
+
+// syncReadFile scans file line by line with a single scanner, calling fn
+// with every line when it is not nil, and returns the number of lines.
+// path is the path of the file, for the errors.
+func (r *Reader) syncReadFile(path string, file io.Reader, fn func(number int64, line []byte) error) (Stats, int64, error) {
+	// count what the scanner pulls out of the file
+	counter := &countingReader{r: file}
+	scanner := r.newScanner(counter)
+
+	var lines int64
+	var fnErr error
 	for scanner.Scan() {
-		_ = scanner.Text()
+		lines++
+		if fn == nil {
+			_ = scanner.Text()
+			continue
+		}
+		if fnErr = fn(lines, r.transform(scanner.Bytes())); fnErr != nil {
+			break
+		}
+	}
+
+	stats := Stats{
+		BytesRead:      counter.n,
+		ChunkCount:     1,
+		GoroutinesUsed: 1,
+		Strategy:       StrategySequential,
+	}
+	if fnErr != nil {
+		return stats, lines, fnErr
+	}
+	return stats, lines, readErr(path, PhaseRead, -1, r.scanErr(scanner.Err(), lines))
+}
+
+// newScanner returns a scanner over src splitting with r's split
+// function, whose buffer grows up to r's MaxLineSize.
+func (r *Reader) newScanner(src io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(src)
+
+	// start with the default buffer size of 512kB at most,
+	// the scanner grows it when needed
+	size := r.cfg.MaxLineSize
+	if size > syncBufferSize {
+		size = syncBufferSize
+	}
+	buf := make([]byte, size)
+	scanner.Buffer(buf, r.cfg.MaxLineSize)
+
+	if r.cfg.Split != nil {
+		scanner.Split(r.cfg.Split)
+	} else {
+		scanner.Split(r.cfg.EOL.split())
+	}
+	return scanner
+}
+
+// transform applies r's Transform to line, when there is one.
+func (r *Reader) transform(line []byte) []byte {
+	if r.cfg.Transform == nil {
+		return line
+	}
+	return r.cfg.Transform(line)
+}
+
+// scanErr explains the scanner failing on a line longer than its buffer,
+// the bare bufio.ErrTooLong doesn't say much. lines is the number of
+// lines scanned successfully before the error.
+func (r *Reader) scanErr(err error, lines int64) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		what := "line"
+		if r.cfg.Split != nil {
+			what = "token"
+		}
+		return fmt.Errorf("%s %d is longer than the MaxLineSize of %d bytes, a bigger buffer is needed: %w", what, lines+1, r.cfg.MaxLineSize, err)
+	}
+	return err
+}
+
+// openSequential opens the file at path for a sequential read,
+// decompressing it when it is gzip compressed. Closing the returned
+// reader closes the file.
+func (r *Reader) openSequential(path string) (io.ReadCloser, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// detecting gzip needs a ReadAt, which pipes and devices lack
+	var gz *gzip.Reader
+	if fileStats.Mode().IsRegular() {
+		gz, err = r.gzipReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	var rc io.ReadCloser = file
+	if gz != nil {
+		rc = &gzipFile{Reader: gz, file: file}
+	}
+
+	src, err := r.stripBOMReader(rc)
+	if err != nil {
+		rc.Close()
+		return nil, readErr(path, PhaseRead, 0, err)
 	}
+	return readCloser{src, rc}, nil
 }
@@ -0,0 +1,903 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeTmp writes data to a file of a temporary directory
+// and returns its path.
+func writeTmp(t testing.TB, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// randData returns n random bytes, the same for the same n.
+func randData(n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(data)
+	return data
+}
+
+// lineData returns n bytes of random lines, the same for the same n.
+func lineData(n int) []byte {
+	rnd := rand.New(rand.NewSource(int64(n)))
+	data := make([]byte, n)
+	for i := range data {
+		if rnd.Intn(40) == 0 {
+			data[i] = '\n'
+		} else {
+			data[i] = byte('a' + rnd.Intn(26))
+		}
+	}
+	return data
+}
+
+// hugeReaderAt pretends to be a file of any size, its reads
+// succeed without touching the buffer.
+type hugeReaderAt struct{}
+
+func (hugeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return len(p), nil
+}
+
+func TestChunksOverTwoGB(t *testing.T) {
+	const size = 1<<31 + 1
+	r := NewReader(ReaderConfig{})
+
+	if got := ChunksFor(size, asyncChunkSize); got != 2049 {
+		t.Fatalf("ChunksFor(%d) = %d, want 2049", int64(size), got)
+	}
+	grid := r.chunkGrid(0, size)
+	if grid.count != 2049 || grid.offset(2048) != 1<<31 {
+		t.Fatalf("chunkGrid(%d) has %d chunks, the last at %d", int64(size), grid.count, grid.offset(grid.count-1))
+	}
+
+	var chunks, bytes int64
+	hooks := chunkHooks{
+		buffer: r.getBuffer,
+		handle: func(offset int64, data []byte) error {
+			defer r.putBuffer(data)
+			atomic.AddInt64(&chunks, 1)
+			atomic.AddInt64(&bytes, int64(len(data)))
+			return nil
+		},
+	}
+	stats, err := r.readChunks(context.Background(), hugeReaderAt{}, size, hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 2049 || bytes != size || stats.BytesRead != size || stats.ChunkCount != 2049 {
+		t.Fatalf("read %d chunks of %d bytes, stats %+v", chunks, bytes, stats)
+	}
+}
+
+func TestStrategiesAgree(t *testing.T) {
+	data := lineData(5*asyncChunkSize + 123)
+	copy(data[asyncChunkSize-2:], "needle")
+	copy(data[3*asyncChunkSize+7:], "needle")
+	path := writeTmp(t, data)
+
+	wantLines := int64(bytes.Count(data, []byte("\n")))
+	if data[len(data)-1] != '\n' {
+		wantLines++
+	}
+
+	for _, strategy := range []Strategy{StrategyReadAt, StrategySequential, StrategyMmap, StrategyAdaptive} {
+		r := NewReader(ReaderConfig{Strategy: strategy, SyncThreshold: -1})
+
+		got, err := r.ReadAsync(path)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%v: ReadAsync = %d bytes, %v", strategy, len(got), err)
+		}
+		lines, err := r.CountLines(path)
+		if err != nil || lines != wantLines {
+			t.Errorf("%v: CountLines = %d, %v, want %d", strategy, lines, err, wantLines)
+		}
+		offsets, err := r.Search(path, []byte("needle"))
+		if err != nil || len(offsets) != 2 || offsets[0] != asyncChunkSize-2 || offsets[1] != 3*asyncChunkSize+7 {
+			t.Errorf("%v: Search = %v, %v", strategy, offsets, err)
+		}
+	}
+}
+
+func TestReadAsyncParallelFiles(t *testing.T) {
+	files := [][]byte{randData(5*asyncChunkSize + 3), randData(2*asyncChunkSize + 9)}
+	r := NewReader(ReaderConfig{SyncThreshold: -1})
+
+	errs := make(chan error, len(files))
+	for _, data := range files {
+		path := writeTmp(t, data)
+		go func() {
+			got, err := r.ReadAsync(path)
+			if err == nil && !bytes.Equal(got, data) {
+				err = fmt.Errorf("%s: read %d bytes differing from the %d written", path, len(got), len(data))
+			}
+			errs <- err
+		}()
+	}
+	for range files {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestReadAsyncPartialLastChunk(t *testing.T) {
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000})
+	for _, size := range []int{1, 999, 1001, 2500, 10007} {
+		data := randData(size)
+		got, stats, err := r.ReadAsyncStats(writeTmp(t, data))
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, data) || stats.BytesRead != int64(size) {
+			t.Errorf("size %d: read %d bytes, stats report %d", size, len(got), stats.BytesRead)
+		}
+	}
+}
+
+func TestReadAsyncCtxCancelAfterFirstChunk(t *testing.T) {
+	path := writeTmp(t, randData(50*1000))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var chunks int64
+	r := NewReader(ReaderConfig{
+		SyncThreshold: -1,
+		ChunkSize:     1000,
+		Concurrency:   1,
+		OnChunk: func(offset int64, data []byte) {
+			atomic.AddInt64(&chunks, 1)
+			cancel()
+		},
+	})
+	if _, err := r.ReadAsyncCtx(ctx, path); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReadAsyncCtx = %v, want context.Canceled", err)
+	}
+	if n := atomic.LoadInt64(&chunks); n >= 50 {
+		t.Errorf("read all the %d chunks after the cancel", n)
+	}
+}
+
+func TestReadAsyncGoroutinePeak(t *testing.T) {
+	const workers = 4
+	path := writeTmp(t, randData(200*1000))
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 100, Concurrency: workers})
+
+	before := runtime.NumGoroutine()
+	stop := make(chan struct{})
+	peak := make(chan int)
+	go func() {
+		max := 0
+		for {
+			select {
+			case <-stop:
+				peak <- max
+				return
+			default:
+			}
+			if n := runtime.NumGoroutine(); n > max {
+				max = n
+			}
+			runtime.Gosched()
+		}
+	}()
+
+	_, err := r.ReadAsync(path)
+	close(stop)
+	max := <-peak
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the sampler, the workers and a goroutine or two of the read
+	if max > before+1+workers+2 {
+		t.Errorf("%d goroutines for %d workers over 2000 chunks, %d before the read", max, workers, before)
+	}
+}
+
+func TestReadAsyncFromBytesReader(t *testing.T) {
+	data := randData(10007)
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 100})
+
+	got, err := r.ReadAsyncFrom(bytes.NewReader(data), int64(len(data)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAsyncFrom = %d bytes, %v", len(got), err)
+	}
+	// the first size bytes only
+	got, err = r.ReadAsyncFrom(bytes.NewReader(data), 5050)
+	if err != nil || !bytes.Equal(got, data[:5050]) {
+		t.Fatalf("ReadAsyncFrom of 5050 bytes = %d bytes, %v", len(got), err)
+	}
+	// a negative size fails before anything is allocated
+	for _, cfg := range []ReaderConfig{{}, {SyncThreshold: -1}} {
+		_, err = NewReader(cfg).ReadAsyncFrom(bytes.NewReader(data), -1)
+		var re *ReadError
+		if !errors.Is(err, ErrInvalidSize) || !errors.As(err, &re) {
+			t.Fatalf("ReadAsyncFrom of -1 bytes = %v, want ErrInvalidSize", err)
+		}
+	}
+	section := io.NewSectionReader(bytes.NewReader(data), 333, 4000)
+	got, err = r.ReadAsyncFrom(section, section.Size())
+	if err != nil || !bytes.Equal(got, data[333:4333]) {
+		t.Fatalf("ReadAsyncFrom of a section = %d bytes, %v", len(got), err)
+	}
+}
+
+func TestReadAsyncSmallFileSequential(t *testing.T) {
+	data := randData(1024)
+	path := writeTmp(t, data)
+
+	got, stats, err := ReadAsyncStats(path)
+	if err != nil || !bytes.Equal(got, data) || stats.Strategy != StrategySequential {
+		t.Errorf("ReadAsyncStats of 1KB = %d bytes, %v, strategy %v", len(got), err, stats.Strategy)
+	}
+	got, stats, err = NewReader(ReaderConfig{SyncThreshold: -1}).ReadAsyncStats(path)
+	if err != nil || !bytes.Equal(got, data) || stats.Strategy != StrategyReadAt {
+		t.Errorf("ReadAsyncStats of 1KB without threshold = %d bytes, %v, strategy %v", len(got), err, stats.Strategy)
+	}
+}
+
+func TestReadDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	reads := map[string]func(string) error{
+		"ReadAsync": func(path string) error {
+			_, err := ReadAsync(path)
+			return err
+		},
+		"ReadSync": ReadSync,
+		"ScanAsync": func(path string) error {
+			return ScanAsync(path, func(offset int64, data []byte) error { return nil })
+		},
+		"CountLines": func(path string) error {
+			_, err := CountLines(path)
+			return err
+		},
+	}
+	for name, read := range reads {
+		err := read(dir)
+		var re *ReadError
+		if !errors.Is(err, ErrIsDirectory) || !errors.As(err, &re) || re.Path != dir {
+			t.Errorf("%s of a directory = %v, want ErrIsDirectory", name, err)
+		}
+	}
+}
+
+func TestReadAsyncFileChanging(t *testing.T) {
+	data := randData(10 * 1000)
+
+	// resize runs once the first chunk was read
+	read := func(path string, stable bool, resize func() error) ([]byte, error) {
+		var once sync.Once
+		r := NewReader(ReaderConfig{
+			SyncThreshold: -1,
+			ChunkSize:     1000,
+			Concurrency:   1,
+			Stable:        stable,
+			OnChunk: func(offset int64, data []byte) {
+				once.Do(func() {
+					if err := resize(); err != nil {
+						t.Error(err)
+					}
+				})
+			},
+		})
+		return r.ReadAsync(path)
+	}
+	grow := func(path string) func() error {
+		return func() error {
+			file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = file.Write([]byte("appended"))
+			return err
+		}
+	}
+
+	// the appended bytes are missed, and only Stable tells
+	path := writeTmp(t, data)
+	got, err := read(path, false, grow(path))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("ReadAsync of a growing file = %d bytes, %v", len(got), err)
+	}
+	path = writeTmp(t, data)
+	if _, err := read(path, true, grow(path)); !errors.Is(err, ErrFileChanged) {
+		t.Errorf("Stable ReadAsync of a growing file = %v, want ErrFileChanged", err)
+	}
+
+	// the chunks past the new end fail either way
+	for _, stable := range []bool{false, true} {
+		path = writeTmp(t, data)
+		_, err := read(path, stable, func() error { return os.Truncate(path, 2500) })
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ReadAsync of a truncated file, Stable %v = %v, want io.ErrUnexpectedEOF", stable, err)
+		}
+	}
+}
+
+func TestReadAsyncChunkCounts(t *testing.T) {
+	const cs = 1000
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: cs})
+
+	for _, tt := range []struct {
+		size   int
+		chunks int
+	}{
+		{0, 0},
+		{1, 1},
+		{cs - 1, 1},
+		{cs, 1},
+		{cs + 1, 2},
+		{2 * cs, 2},
+	} {
+		data := randData(tt.size)
+		got, stats, err := r.ReadAsyncStats(writeTmp(t, data))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("ReadAsyncStats of %d bytes = %d bytes, %v", tt.size, len(got), err)
+		}
+		if stats.ChunkCount != tt.chunks || stats.BytesRead != int64(tt.size) {
+			t.Errorf("%d bytes: read %d chunks and %d bytes, want %d chunks", tt.size, stats.ChunkCount, stats.BytesRead, tt.chunks)
+		}
+	}
+}
+
+// flakyReaderAt fails the first reads at some offsets, then reads
+// from src.
+type flakyReaderAt struct {
+	src io.ReaderAt
+
+	mu    sync.Mutex
+	fails map[int64]int // failures left at the offset
+	calls int
+}
+
+var errTransient = errors.New("transient failure")
+
+func (f *flakyReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	f.calls++
+	if f.fails[off] > 0 {
+		f.fails[off]--
+		f.mu.Unlock()
+		return 0, errTransient
+	}
+	f.mu.Unlock()
+	return f.src.ReadAt(p, off)
+}
+
+func TestReadAsyncRetries(t *testing.T) {
+	data := randData(10 * 1024)
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1024, MaxAttempts: 3, RetryBackoff: time.Millisecond})
+
+	// 10 chunks, and 3 failures under the attempts limit
+	src := &flakyReaderAt{src: bytes.NewReader(data), fails: map[int64]int{2048: 2, 4096: 1}}
+	got, err := r.ReadAsyncFrom(src, int64(len(data)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAsyncFrom with transient failures = %d bytes, %v", len(got), err)
+	}
+	if src.calls != 13 {
+		t.Errorf("%d ReadAt calls, want 13", src.calls)
+	}
+
+	src = &flakyReaderAt{src: bytes.NewReader(data), fails: map[int64]int{2048: 5}}
+	_, err = r.ReadAsyncFrom(src, int64(len(data)))
+	var re *ReadError
+	if !errors.Is(err, errTransient) || !errors.As(err, &re) || re.Offset != 2048 {
+		t.Fatalf("ReadAsyncFrom with a persistent failure = %v, want errTransient at offset 2048", err)
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") || src.fails[2048] != 2 {
+		t.Errorf("ReadAsyncFrom = %v with %d failures left, want 3 attempts", err, src.fails[2048])
+	}
+}
+
+// failingReaderAt fails every read from offset on.
+type failingReaderAt struct {
+	offset int64
+}
+
+func (f failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.offset {
+		return 0, errTransient
+	}
+	return len(p), nil
+}
+
+func TestReadAsyncNoLeak(t *testing.T) {
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 100, Concurrency: 8})
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		if _, err := r.ReadAsyncFrom(failingReaderAt{offset: 5000}, 100*1000); !errors.Is(err, errTransient) {
+			t.Fatalf("ReadAsyncFrom = %v, want errTransient", err)
+		}
+		err := r.ScanAsync(writeTmp(t, randData(100*1000)), func(offset int64, data []byte) error {
+			if offset >= 5000 {
+				return errTransient
+			}
+			return nil
+		})
+		if !errors.Is(err, errTransient) {
+			t.Fatalf("ScanAsync = %v, want errTransient", err)
+		}
+		err = r.ReadAsyncStream(writeTmp(t, randData(100*1000)), func(offset int64, data []byte) error {
+			if offset >= 5000 {
+				return errTransient
+			}
+			return nil
+		})
+		if !errors.Is(err, errTransient) {
+			t.Fatalf("ReadAsyncStream = %v, want errTransient", err)
+		}
+	}
+
+	// give the goroutines which are done a moment to exit
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines after the failed reads, %d before", after, before)
+	}
+}
+
+func TestReadAsyncMaxBytesPerSec(t *testing.T) {
+	const size, rate = 400 * 1000, 1000 * 1000
+	data := randData(size)
+	path := writeTmp(t, data)
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 10 * 1000, MaxBytesPerSec: rate})
+
+	start := time.Now()
+	got, err := r.ReadAsync(path)
+	elapsed := time.Since(start)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAsync = %d bytes, %v", len(got), err)
+	}
+	// the bucket starts with one chunk in it, the rest waits for
+	// its tokens: 0.39s at least, and well under a few seconds
+	if elapsed < 350*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("read %d bytes at %d bytes/s in %v, want about 0.4s", size, rate, elapsed)
+	}
+}
+
+func TestReadAsyncFilePipe(t *testing.T) {
+	data := randData(300 * 1000)
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	go func() {
+		pw.Write(data)
+		pw.Close()
+	}()
+
+	// a pipe can't be read at random offsets, it can only be read
+	// through, whatever the config asks for
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000, Strategy: StrategyMmap})
+	got, err := r.ReadAsyncFile(pr)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAsyncFile of a pipe = %d bytes, %v", len(got), err)
+	}
+}
+
+func TestReadAsyncReverseOrder(t *testing.T) {
+	data := randData(100*1000 + 77)
+	path := writeTmp(t, data)
+	forward := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000})
+	reverse := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000, Concurrency: 1, ReverseOrder: true})
+
+	want, err := forward.ReadAsync(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reverse.ReadAsync(path)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Errorf("ReadAsync in reverse order = %d bytes, %v, differing from the forward read", len(got), err)
+	}
+
+	var streamed bytes.Buffer
+	err = reverse.ReadAsyncStream(path, func(offset int64, data []byte) error {
+		streamed.Write(data)
+		return nil
+	})
+	if err != nil || !bytes.Equal(streamed.Bytes(), want) {
+		t.Errorf("ReadAsyncStream in reverse order = %d bytes, %v, differing from the forward read", streamed.Len(), err)
+	}
+
+	// a single worker sees the chunks last to first
+	var offsets []int64
+	err = reverse.ScanAsync(path, func(offset int64, data []byte) error {
+		offsets = append(offsets, offset)
+		return nil
+	})
+	if err != nil || len(offsets) != 101 || offsets[0] != 100*1000 || offsets[100] != 0 {
+		t.Errorf("ScanAsync in reverse order saw the chunks at %v, %v", offsets, err)
+	}
+}
+
+// TestConcurrentReads is meant for go test -race: many reads at the
+// same time through a shared Reader, its buffer pool, and through the
+// default Reader.
+func TestConcurrentReads(t *testing.T) {
+	data := lineData(50*1000 + 11)
+	path := writeTmp(t, data)
+	wantLines, err := SyncCountLines(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		for _, r := range []*Reader{shared, defaultReader} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := r.ReadAsync(path)
+				if err != nil || !bytes.Equal(got, data) {
+					t.Errorf("ReadAsync = %d bytes, %v", len(got), err)
+				}
+				lines, err := r.CountLines(path)
+				if err != nil || lines != wantLines {
+					t.Errorf("CountLines = %d, %v, want %d", lines, err, wantLines)
+				}
+				var size int64
+				err = r.ScanAsync(path, func(offset int64, data []byte) error {
+					atomic.AddInt64(&size, int64(len(data)))
+					return nil
+				})
+				if err != nil || size != int64(len(data)) {
+					t.Errorf("ScanAsync saw %d bytes, %v", size, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestTinyFiles(t *testing.T) {
+	configs := map[string]ReaderConfig{
+		"default":    {},
+		"ReadAt":     {SyncThreshold: -1},
+		"Sequential": {SyncThreshold: -1, Strategy: StrategySequential},
+		"Mmap":       {SyncThreshold: -1, Strategy: StrategyMmap},
+		"Adaptive":   {SyncThreshold: -1, Strategy: StrategyAdaptive},
+		"Reverse":    {SyncThreshold: -1, ReverseOrder: true},
+		"AlignTo":    {SyncThreshold: -1, AlignTo: 4096},
+	}
+	for _, tt := range []struct {
+		data  []byte
+		lines int64
+	}{
+		{[]byte{}, 0},
+		{[]byte{'x'}, 1},
+		{[]byte{'\n'}, 1},
+	} {
+		path := writeTmp(t, tt.data)
+		wantSHA := sha256.Sum256(tt.data)
+		wantCRC := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(tt.data))
+
+		for name, cfg := range configs {
+			r := NewReader(cfg)
+			got, err := r.ReadAsync(path)
+			if err != nil || got == nil || !bytes.Equal(got, tt.data) {
+				t.Errorf("%s: ReadAsync(%q) = %q, %v", name, tt.data, got, err)
+			}
+			lines, err := r.CountLines(path)
+			if err != nil || lines != tt.lines {
+				t.Errorf("%s: CountLines(%q) = %d, %v, want %d", name, tt.data, lines, err, tt.lines)
+			}
+			lines, err = r.SyncCountLines(path)
+			if err != nil || lines != tt.lines {
+				t.Errorf("%s: SyncCountLines(%q) = %d, %v, want %d", name, tt.data, lines, err, tt.lines)
+			}
+			sum, err := r.Checksum(path, ChecksumSHA256)
+			if err != nil || !bytes.Equal(sum, wantSHA[:]) {
+				t.Errorf("%s: SHA-256 of %q = %x, %v", name, tt.data, sum, err)
+			}
+			sum, err = r.Checksum(path, ChecksumCRC32)
+			if err != nil || !bytes.Equal(sum, wantCRC) {
+				t.Errorf("%s: CRC-32 of %q = %x, %v", name, tt.data, sum, err)
+			}
+		}
+	}
+}
+
+// fakeResult is what a fakeReaderAt returns at an offset: n bytes,
+// all it was asked for when n is negative, and err.
+type fakeResult struct {
+	n   int
+	err error
+}
+
+// fakeReaderAt reads data, but for the results injected at
+// some offsets.
+type fakeReaderAt struct {
+	data    []byte
+	results map[int64]fakeResult
+}
+
+func (f fakeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	res, ok := f.results[off]
+	if !ok {
+		n := copy(p, f.data[off:])
+		if n < len(p) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+	if res.n < 0 || res.n > len(p) {
+		res.n = len(p)
+	}
+	copy(p[:res.n], f.data[off:])
+	return res.n, res.err
+}
+
+func TestReadAsyncFromInjectedResults(t *testing.T) {
+	const cs = 1024
+	data := randData(10*cs + 17)
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: cs, MaxAttempts: 1})
+
+	for _, tt := range []struct {
+		name    string
+		offset  int64
+		result  fakeResult
+		wantErr error // nil when the read must succeed
+	}{
+		{"full read with io.EOF", 10 * cs, fakeResult{-1, io.EOF}, nil},
+		{"full read with io.EOF mid file", 4 * cs, fakeResult{-1, io.EOF}, nil},
+		{"io.EOF without bytes", 2 * cs, fakeResult{0, io.EOF}, io.ErrUnexpectedEOF},
+		{"short read with io.EOF", 5 * cs, fakeResult{10, io.EOF}, io.ErrUnexpectedEOF},
+		{"short read without error", 3 * cs, fakeResult{100, nil}, ErrShortRead},
+		{"no bytes without error", 10 * cs, fakeResult{0, nil}, ErrShortRead},
+		{"error", 7 * cs, fakeResult{0, errTransient}, errTransient},
+		{"error with the bytes", 0, fakeResult{-1, errTransient}, errTransient},
+	} {
+		src := fakeReaderAt{data: data, results: map[int64]fakeResult{tt.offset: tt.result}}
+		got, err := r.ReadAsyncFrom(src, int64(len(data)))
+		if tt.wantErr == nil {
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("%s: ReadAsyncFrom = %d bytes, %v", tt.name, len(got), err)
+			}
+			continue
+		}
+
+		var re *ReadError
+		if !errors.Is(err, tt.wantErr) || !errors.As(err, &re) {
+			t.Errorf("%s: ReadAsyncFrom = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if re.Phase != PhaseRead || re.Offset != tt.offset {
+			t.Errorf("%s: ReadAsyncFrom failed in %s at offset %d, want %s at %d", tt.name, re.Phase, re.Offset, PhaseRead, tt.offset)
+		}
+	}
+
+	// a size past the end of src
+	_, err := r.ReadAsyncFrom(fakeReaderAt{data: data}, int64(len(data))+cs)
+	var re *ReadError
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &re) || re.Offset != 10*cs {
+		t.Errorf("ReadAsyncFrom past the end = %v, want io.ErrUnexpectedEOF at offset %d", err, 10*cs)
+	}
+}
+
+func TestCancelledChunksNotHandled(t *testing.T) {
+	const size = 40 * 1000
+	hung := hungReaderAt{unblock: make(chan struct{})}
+	defer close(hung.unblock)
+
+	// a read cancelled by its deadline hands no chunk over
+	var chunks, progressed int64
+	r := NewReader(ReaderConfig{
+		SyncThreshold: -1,
+		ChunkSize:     10 * 1000,
+		Concurrency:   4,
+		ChunkTimeout:  time.Minute,
+		OnChunk:       func(offset int64, data []byte) { atomic.AddInt64(&chunks, 1) },
+		Progress: func(bytesDone, total int64, eta time.Duration) {
+			atomic.AddInt64(&progressed, 1)
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := r.asyncRead(ctx, "", hung, 0, size, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("asyncRead = %v, want context.DeadlineExceeded", err)
+	}
+	if chunks != 0 || progressed != 0 {
+		t.Errorf("a cancelled read called OnChunk %d times and Progress %d times", chunks, progressed)
+	}
+
+	// neither does a read stopped by a failing chunk
+	src := readerAtFunc(func(p []byte, off int64) (int, error) {
+		if off == 0 {
+			// once the other chunks are being read
+			time.Sleep(50 * time.Millisecond)
+			return 0, errTransient
+		}
+		return hung.ReadAt(p, off)
+	})
+	var handled int64
+	hooks := chunkHooks{
+		buffer: r.getBuffer,
+		handle: func(offset int64, data []byte) error {
+			atomic.AddInt64(&handled, 1)
+			return nil
+		},
+	}
+	if _, err := r.readChunks(context.Background(), src, size, hooks); !errors.Is(err, errTransient) {
+		t.Fatalf("readChunks = %v, want errTransient", err)
+	}
+	if handled != 0 {
+		t.Errorf("%d chunks handled after the first one failed", handled)
+	}
+}
+
+// readerAtFunc is a function as an io.ReaderAt.
+type readerAtFunc func(p []byte, off int64) (int, error)
+
+func (f readerAtFunc) ReadAt(p []byte, off int64) (int, error) {
+	return f(p, off)
+}
+
+func TestReadAsyncRetryCancelled(t *testing.T) {
+	data := randData(10 * 1024)
+	src := &flakyReaderAt{src: bytes.NewReader(data), fails: map[int64]int{2048: 5}}
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1024, MaxAttempts: 3, RetryBackoff: time.Minute})
+
+	var mu sync.Mutex
+	var offsets []int64
+	hooks := chunkHooks{
+		buffer: r.getBuffer,
+		handle: func(offset int64, data []byte) error {
+			mu.Lock()
+			offsets = append(offsets, offset)
+			mu.Unlock()
+			return nil
+		},
+	}
+	// the deadline expires while the chunk at 2048 waits to be retried
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.readChunks(ctx, src, int64(len(data)), hooks); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("readChunks = %v, want context.DeadlineExceeded", err)
+	}
+	if slices.Contains(offsets, 2048) {
+		t.Errorf("the chunk at 2048 was handled without being read, chunks handled at %v", offsets)
+	}
+}
+
+func TestReadAsyncThrottleCancelled(t *testing.T) {
+	data := randData(10 * 1000)
+	src := &countingReaderAt{Reader: bytes.NewReader(data)}
+	// the bucket holds the first chunk, the others wait a second each
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000, Concurrency: 4, MaxBytesPerSec: 1000})
+
+	var handled int64
+	hooks := chunkHooks{
+		buffer: r.getBuffer,
+		handle: func(offset int64, data []byte) error {
+			atomic.AddInt64(&handled, 1)
+			return nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := r.readChunks(ctx, src, int64(len(data)), hooks); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("readChunks = %v, want context.DeadlineExceeded", err)
+	}
+	if calls := atomic.LoadInt64(&src.calls); calls != 1 || handled != 1 {
+		t.Errorf("%d ReadAt calls and %d chunks handled, want only the first chunk", calls, handled)
+	}
+}
+
+func TestReadErrorEntryPoints(t *testing.T) {
+	errCallback := errors.New("callback failure")
+
+	// an archive with a file and a directory
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755})
+	tw.WriteHeader(&tar.Header{Name: "dir/file", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4})
+	tw.Write([]byte("data"))
+	tw.Close()
+	tarPath := writeTmp(t, archive.Bytes())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/head-fails":
+			w.WriteHeader(http.StatusInternalServerError)
+		case req.Method == http.MethodHead:
+			// no Accept-Ranges, the GET follows
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dataPath := writeTmp(t, randData(100))
+	jsonPath := writeTmp(t, []byte("{\"a\": 1}\n{broken\n"))
+	missingDir := filepath.Join(t.TempDir(), "missing", "f")
+
+	for _, tt := range []struct {
+		name  string
+		path  string
+		phase string
+		is    error
+		err   error
+	}{
+		{name: "ReadRange", path: dataPath, phase: PhaseStat, is: ErrInvalidRange, err: func() error {
+			_, err := ReadRange(dataPath, 50, 51)
+			return err
+		}()},
+		{name: "ReadSince", path: dataPath, phase: PhaseStat, is: ErrInvalidRange, err: func() error {
+			_, _, err := ReadSince(dataPath, -1)
+			return err
+		}()},
+		{name: "ReadTarEntry missing", path: tarPath, phase: PhaseOpen, is: ErrEntryNotFound, err: func() error {
+			_, err := ReadTarEntry(tarPath, "nothing")
+			return err
+		}()},
+		{name: "ReadTarEntry directory", path: tarPath, phase: PhaseOpen, err: func() error {
+			_, err := ReadTarEntry(tarPath, "dir/")
+			return err
+		}()},
+		{name: "ReadAsyncURL HEAD", path: server.URL + "/head-fails", phase: PhaseStat, is: ErrHTTPStatus, err: func() error {
+			_, err := ReadAsyncURL(server.URL + "/head-fails")
+			return err
+		}()},
+		{name: "ReadAsyncURL GET", path: server.URL + "/get-fails", phase: PhaseOpen, is: ErrHTTPStatus, err: func() error {
+			_, err := ReadAsyncURL(server.URL + "/get-fails")
+			return err
+		}()},
+		{name: "WriteAsync empty path", phase: PhaseOpen, is: ErrEmptyPath, err: WriteAsync("", nil)},
+		{name: "WriteAsync missing directory", path: missingDir, phase: PhaseOpen, is: fs.ErrNotExist, err: WriteAsync(missingDir, []byte("data"))},
+		{name: "DecodeJSONLines invalid", path: jsonPath, phase: PhaseRead, is: ErrInvalidJSON, err: DecodeJSONLines(jsonPath, func(raw json.RawMessage) error {
+			return nil
+		})},
+		{name: "DecodeJSONLines callback", path: jsonPath, phase: PhaseRead, is: errCallback, err: DecodeJSONLines(jsonPath, func(raw json.RawMessage) error {
+			return errCallback
+		})},
+	} {
+		var re *ReadError
+		if !errors.As(tt.err, &re) {
+			t.Errorf("%s = %v, want a ReadError", tt.name, tt.err)
+			continue
+		}
+		if re.Path != tt.path || re.Phase != tt.phase || tt.is != nil && !errors.Is(tt.err, tt.is) {
+			t.Errorf("%s = %v, want a ReadError of %q in %s wrapping %v", tt.name, tt.err, tt.path, tt.phase, tt.is)
+		}
+	}
+}
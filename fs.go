@@ -0,0 +1,55 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"io"
+	"io/fs"
+)
+
+// ReadAsyncFS is like ReadAsync but reads the file name of fsys, an
+// embed.FS, a zip.Reader, an fstest.MapFS... The chunks are read
+// concurrently when the file implements io.ReaderAt, as the files of
+// embed.FS do, otherwise the file is read sequentially like fs.ReadFile
+// does. gzip compressed files are not decompressed.
+func ReadAsyncFS(fsys fs.FS, name string) ([]byte, error) {
+	return defaultReader.ReadAsyncFS(fsys, name)
+}
+
+// ReadAsyncFS is the package level ReadAsyncFS using r's config.
+func (r *Reader) ReadAsyncFS(fsys fs.FS, name string) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, readErr(name, PhaseOpen, -1, err)
+	}
+	defer file.Close()
+
+	fileStats, err := file.Stat()
+	if err != nil {
+		return nil, readErr(name, PhaseStat, -1, err)
+	}
+	if fileStats.IsDir() {
+		return nil, readErr(name, PhaseOpen, -1, ErrIsDirectory)
+	}
+
+	src, ok := file.(io.ReaderAt)
+	if !ok || !fileStats.Mode().IsRegular() {
+		r.cfg.Logger.Printf("filereader: %s has no ReadAt, reading it sequentially", name)
+		data, _, err := r.readStreamAll(name, file, nil)
+		return data, err
+	}
+
+	skip, err := r.skipBOM(src)
+	if err != nil {
+		return nil, readErr(name, PhaseRead, 0, err)
+	}
+	size := fileStats.Size() - skip
+
+	data, _, err := r.asyncRead(context.Background(), name, io.NewSectionReader(src, skip, size), skip, size, nil)
+	return data, err
+}
@@ -0,0 +1,69 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed testdata/embedded.txt
+var embedded embed.FS
+
+// noReadAtFS hides the ReadAt of the files of fsys.
+type noReadAtFS struct {
+	fsys fs.FS
+}
+
+type noReadAtFile struct {
+	fs.File
+}
+
+func (n noReadAtFS) Open(name string) (fs.File, error) {
+	file, err := n.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return noReadAtFile{file}, nil
+}
+
+func TestReadAsyncFS(t *testing.T) {
+	want, err := os.ReadFile("testdata/embedded.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapFS := fstest.MapFS{
+		"dir/data.bin": {Data: randData(10*1000 + 3)},
+		"empty":        {Data: []byte{}},
+	}
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 100})
+
+	for _, tt := range []struct {
+		name string
+		fsys fs.FS
+		file string
+		want []byte
+	}{
+		{"embed.FS", embedded, "testdata/embedded.txt", want},
+		{"MapFS", mapFS, "dir/data.bin", mapFS["dir/data.bin"].Data},
+		{"empty MapFS file", mapFS, "empty", []byte{}},
+		{"no ReadAt", noReadAtFS{mapFS}, "dir/data.bin", mapFS["dir/data.bin"].Data},
+	} {
+		got, err := r.ReadAsyncFS(tt.fsys, tt.file)
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: ReadAsyncFS = %d bytes, %v, want %d", tt.name, len(got), err, len(tt.want))
+		}
+	}
+
+	if _, err := r.ReadAsyncFS(mapFS, "dir"); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("ReadAsyncFS of a directory = %v, want ErrIsDirectory", err)
+	}
+	if _, err := r.ReadAsyncFS(embedded, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadAsyncFS of a missing file = %v, want fs.ErrNotExist", err)
+	}
+}
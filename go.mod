@@ -0,0 +1,9 @@
+module github.com/bigfoot31/fastFileReader
+
+go 1.22
+
+require (
+	golang.org/x/sync v0.10.0
+	golang.org/x/sys v0.28.0
+	golang.org/x/time v0.8.0
+)
@@ -0,0 +1,74 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// Match is a line matched by Grep.
+type Match struct {
+	// Offset is the byte offset of the match in the file.
+	Offset int64
+
+	// Line is the line holding the match, without its end of line.
+	Line string
+}
+
+// Grep returns the lines of the file at path matching re, in file
+// order, like grep. A line is reported once, Offset being the one of
+// its leftmost match.
+//
+// The chunks are searched concurrently, every worker matching the lines
+// lying entirely in its chunk. The lines crossing a chunk boundary are
+// put back together from the pieces of the chunks, and matched once
+// all the chunks are read.
+func Grep(path string, re *regexp.Regexp) ([]Match, error) {
+	return defaultReader.Grep(path, re)
+}
+
+// Grep is the package level Grep using r's config.
+func (r *Reader) Grep(path string, re *regexp.Regexp) ([]Match, error) {
+	matches := []Match{}
+
+	err := r.scanLineChunks(path, func(block []byte, start int64) interface{} {
+		return grepLines(re, block, start)
+	}, func(line []byte, start int64) {
+		if m, ok := grepLine(re, line, start); ok {
+			matches = append(matches, m)
+		}
+	}, func(result interface{}) {
+		matches = append(matches, result.([]Match)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// grepLines matches every '\n' terminated line of block, which starts
+// at offset start in the file.
+func grepLines(re *regexp.Regexp, block []byte, start int64) []Match {
+	var matches []Match
+	for len(block) > 0 {
+		end := bytes.IndexByte(block, '\n')
+		if m, ok := grepLine(re, block[:end], start); ok {
+			matches = append(matches, m)
+		}
+		block = block[end+1:]
+		start += int64(end) + 1
+	}
+	return matches
+}
+
+// grepLine matches line, starting at offset start in the file.
+func grepLine(re *regexp.Regexp, line []byte, start int64) (Match, bool) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+
+	loc := re.FindIndex(line)
+	if loc == nil {
+		return Match{}, false
+	}
+	return Match{Offset: start + int64(loc[0]), Line: string(line)}, true
+}
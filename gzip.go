@@ -0,0 +1,87 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math"
+	"os"
+)
+
+// first two bytes of every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gzipReader returns a gzip.Reader over file when r is configured to
+// decompress gzip input and file starts with the gzip magic bytes,
+// nil otherwise. The caller closes the returned reader.
+func (r *Reader) gzipReader(file *os.File) (*gzip.Reader, error) {
+	if !r.cfg.Gzip {
+		return nil, nil
+	}
+
+	magic := make([]byte, len(gzipMagic))
+	if _, err := file.ReadAt(magic, 0); err != nil {
+		// files shorter than the magic are simply not compressed
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, readErr(file.Name(), PhaseRead, 0, err)
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return nil, nil
+	}
+
+	r.cfg.Logger.Printf("filereader: %s is gzip compressed, reading it sequentially", file.Name())
+
+	// decompress through ReadAt as well, so the file offset is never
+	// moved and a file shared by several reads stays usable
+	gz, err := gzip.NewReader(io.NewSectionReader(file, 0, math.MaxInt64))
+	if err != nil {
+		return nil, readErr(file.Name(), PhaseRead, 0, err)
+	}
+	return gz, nil
+}
+
+// readSequential reads src from start to end in chunks of r's chunk size
+// and calls fn with every chunk in order. Used for sources that can't
+// be read at random offsets, like a gzip stream. path is the path of
+// the file src comes from, for the errors.
+func (r *Reader) readSequential(path string, src io.Reader, fn func(offset int64, data []byte) error) error {
+	buf := r.getBuffer(0, r.cfg.ChunkSize)
+	defer r.putBuffer(buf)
+
+	offset := int64(0)
+	for {
+		n, err := io.ReadFull(src, buf)
+		if n > 0 {
+			if err := fn(offset, buf[:n]); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return readErr(path, PhaseRead, offset, err)
+		}
+	}
+}
+
+// gzipFile is a gzip stream over an open file,
+// closing it closes both.
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if cerr := g.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
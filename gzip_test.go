@@ -0,0 +1,48 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+// gzipData returns data gzip compressed.
+func gzipData(t testing.TB, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGzipMatchesPlain(t *testing.T) {
+	data := lineData(100*1000 + 7)
+	plain, compressed := writeTmp(t, data), writeTmp(t, gzipData(t, data))
+	r := NewReader(ReaderConfig{Gzip: true, ChunkSize: 1000})
+
+	got, err := r.ReadAsync(compressed)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAsync of the gzipped copy = %d bytes, %v", len(got), err)
+	}
+
+	for name, count := range map[string]func(string) (int64, error){
+		"CountLines":     r.CountLines,
+		"SyncCountLines": r.SyncCountLines,
+	} {
+		want, err := count(plain)
+		if err != nil {
+			t.Fatalf("%s of the plain copy: %v", name, err)
+		}
+		lines, err := count(compressed)
+		if err != nil || lines != want {
+			t.Errorf("%s of the gzipped copy = %d, %v, want %d", name, lines, err, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import "errors"
+
+// ErrInvalidHint is returned when ReaderConfig.Hint is unknown.
+var ErrInvalidHint = errors.New("filereader: unknown access hint")
+
+// Hint tells the kernel how a file is going to be read, so it can
+// prefetch accordingly. Hints are only given on linux, with fadvise
+// for StrategyReadAt and madvise for StrategyMmap.
+type Hint int
+
+const (
+	// HintNone gives no hint, the kernel read ahead applies as usual.
+	// It is the default.
+	HintNone Hint = iota
+
+	// HintSequential announces a read from start to end, the kernel
+	// reads ahead more aggressively.
+	HintSequential
+
+	// HintRandom announces reads at random offsets, the kernel
+	// doesn't read ahead.
+	HintRandom
+
+	// HintWillNeed asks the kernel to start loading the whole file
+	// in the page cache right away.
+	HintWillNeed
+)
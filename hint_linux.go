@@ -0,0 +1,45 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build linux
+
+package filereader
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// advice of fadvise and madvise for every Hint
+var (
+	fadvice = map[Hint]int{
+		HintSequential: unix.FADV_SEQUENTIAL,
+		HintRandom:     unix.FADV_RANDOM,
+		HintWillNeed:   unix.FADV_WILLNEED,
+	}
+	madvice = map[Hint]int{
+		HintSequential: unix.MADV_SEQUENTIAL,
+		HintRandom:     unix.MADV_RANDOM,
+		HintWillNeed:   unix.MADV_WILLNEED,
+	}
+)
+
+// advise gives r's Hint for the size first bytes of file to the kernel,
+// on the mapping when src is one. The hint is only advice, failing to
+// give it is logged and the read goes on.
+func (r *Reader) advise(file *os.File, src io.ReaderAt, size int64) {
+	if r.cfg.Hint == HintNone || size == 0 {
+		return
+	}
+
+	var err error
+	if m, ok := src.(*mmapReaderAt); ok {
+		err = unix.Madvise(m.data, madvice[r.cfg.Hint])
+	} else {
+		err = unix.Fadvise(int(file.Fd()), 0, size, fadvice[r.cfg.Hint])
+	}
+	if err != nil {
+		r.cfg.Logger.Printf("filereader: cannot give the access hint for %s: %v", file.Name(), err)
+	}
+}
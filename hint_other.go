@@ -0,0 +1,13 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build !linux
+
+package filereader
+
+import (
+	"io"
+	"os"
+)
+
+// advise does nothing, access hints are only given on linux.
+func (r *Reader) advise(file *os.File, src io.ReaderAt, size int64) {}
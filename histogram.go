@@ -0,0 +1,36 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import "sync"
+
+// ByteHistogram returns how many times every byte value appears in the
+// file at path. Every chunk is counted by its worker into a histogram
+// of its own, and the chunk histograms are summed at the end.
+func ByteHistogram(path string) ([256]int64, error) {
+	return defaultReader.ByteHistogram(path)
+}
+
+// ByteHistogram is the package level ByteHistogram using r's config.
+func (r *Reader) ByteHistogram(path string) ([256]int64, error) {
+	var mu sync.Mutex
+	var total [256]int64
+
+	err := r.ScanAsync(path, func(offset int64, data []byte) error {
+		var local [256]int64
+		for _, b := range data {
+			local[b]++
+		}
+
+		mu.Lock()
+		for i, n := range local {
+			total[i] += n
+		}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return [256]int64{}, err
+	}
+	return total, nil
+}
@@ -0,0 +1,20 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import "testing"
+
+func TestByteHistogram(t *testing.T) {
+	for _, size := range []int{0, 1, 100*1000 + 3} {
+		data := randData(size)
+		var want [256]int64
+		for _, b := range data {
+			want[b]++
+		}
+
+		got, err := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000}).ByteHistogram(writeTmp(t, data))
+		if err != nil || got != want {
+			t.Errorf("ByteHistogram of %d bytes = %v, %v, want %v", size, got, err, want)
+		}
+	}
+}
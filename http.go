@@ -0,0 +1,121 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrHTTPStatus is returned by ReadAsyncURL when the server answers
+// with an unexpected status.
+var ErrHTTPStatus = errors.New("filereader: unexpected http status")
+
+// ReadAsyncURL downloads the resource at url concurrently in chunks like
+// ReadAsync, every chunk being a GET with a Range header, and returns it
+// reassembled in order.
+//
+// A HEAD request first learns the size of the resource. Servers which
+// don't advertise "Accept-Ranges: bytes" or don't send a Content-Length
+// are read with a single sequential GET instead.
+func ReadAsyncURL(url string) ([]byte, error) {
+	return defaultReader.ReadAsyncURL(url)
+}
+
+// ReadAsyncURL is the package level ReadAsyncURL using r's config.
+func (r *Reader) ReadAsyncURL(url string) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	client := r.cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	// the HEAD request is the stat of the resource
+	resp, err := client.Head(url)
+	if err != nil {
+		return nil, readErr(url, PhaseStat, -1, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("%w: HEAD: %s", ErrHTTPStatus, resp.Status)
+		return nil, readErr(url, PhaseStat, -1, err)
+	}
+
+	if resp.Header.Get("Accept-Ranges") != "bytes" || resp.ContentLength < 0 {
+		r.cfg.Logger.Printf("filereader: %s doesn't support range requests, reading it sequentially", url)
+		return r.getURL(client, url)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := &httpReaderAt{ctx: ctx, client: client, url: url}
+	data, _, err := r.asyncRead(ctx, url, src, 0, resp.ContentLength, nil)
+	return data, err
+}
+
+// getURL downloads the whole resource at url with a single GET.
+func (r *Reader) getURL(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, readErr(url, PhaseOpen, -1, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("%w: GET: %s", ErrHTTPStatus, resp.Status)
+		return nil, readErr(url, PhaseOpen, -1, err)
+	}
+
+	data, err := r.readAllLimited(resp.Body)
+	if errors.Is(err, ErrFileTooLarge) {
+		return nil, readErr(url, PhaseAssemble, -1, err)
+	}
+	if err != nil {
+		return nil, readErr(url, PhaseRead, -1, err)
+	}
+	return data, nil
+}
+
+// httpReaderAt reads a remote resource at random offsets
+// with range requests.
+type httpReaderAt struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+}
+
+func (h *httpReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// a 200 would be the whole resource, not the range we asked for
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("%w: GET %s with range at offset %d: %s", ErrHTTPStatus, h.url, off, resp.Status)
+	}
+
+	n, err := io.ReadFull(resp.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,43 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidJSON is returned by DecodeJSONLines for a line which is
+// not valid JSON.
+var ErrInvalidJSON = errors.New("filereader: invalid JSON")
+
+// DecodeJSONLines reads the newline delimited JSON file at path
+// sequentially, with the scanner of ReadSync, and calls fn with the
+// raw JSON of every line. Blank lines are skipped. raw is only valid
+// until fn returns, copy it to keep it.
+//
+// The read stops on the first malformed line or error from fn, the
+// returned error then tells the number of the line.
+func DecodeJSONLines(path string, fn func(raw json.RawMessage) error) error {
+	return defaultReader.DecodeJSONLines(path, fn)
+}
+
+// DecodeJSONLines is the package level DecodeJSONLines using r's config.
+func (r *Reader) DecodeJSONLines(path string, fn func(raw json.RawMessage) error) error {
+	_, err := r.ReadSyncLines(path, func(number int64, line []byte) error {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			return nil
+		}
+		if !json.Valid(line) {
+			return readErr(path, PhaseRead, -1, fmt.Errorf("%w on line %d", ErrInvalidJSON, number))
+		}
+		if err := fn(json.RawMessage(line)); err != nil {
+			return readErr(path, PhaseRead, -1, fmt.Errorf("line %d: %w", number, err))
+		}
+		return nil
+	})
+	return err
+}
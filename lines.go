@@ -0,0 +1,387 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ReadLines returns the lines of the file at path, read sequentially
+// with the same scanner as ReadSync. Lines don't include their end of line.
+// A line longer than the MaxLineSize fails the read, the error then
+// wraps bufio.ErrTooLong.
+func ReadLines(path string) ([]string, error) {
+	return defaultReader.ReadLines(path)
+}
+
+// ReadLines is the package level ReadLines using r's config.
+func (r *Reader) ReadLines(path string) ([]string, error) {
+	src, err := r.openSequential(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	lines := []string{}
+
+	scanner := r.newScanner(src)
+	for scanner.Scan() {
+		lines = append(lines, string(r.transform(scanner.Bytes())))
+	}
+	if err := r.scanErr(scanner.Err(), int64(len(lines))); err != nil {
+		return nil, readErr(path, PhaseRead, -1, err)
+	}
+	return lines, nil
+}
+
+// Head returns the first lines lines of the file at path, or all of
+// them when the file has fewer. It scans the file sequentially like
+// ReadLines but stops as soon as it has enough lines, the rest of the
+// file is never read. lines <= 0 returns an empty slice.
+func Head(path string, lines int) ([]string, error) {
+	return defaultReader.Head(path, lines)
+}
+
+// Head is the package level Head using r's config.
+func (r *Reader) Head(path string, lines int) ([]string, error) {
+	if lines <= 0 {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+		return []string{}, nil
+	}
+
+	src, err := r.openSequential(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	found := []string{}
+
+	scanner := r.newScanner(src)
+	for len(found) < lines && scanner.Scan() {
+		found = append(found, string(r.transform(scanner.Bytes())))
+	}
+	if err := r.scanErr(scanner.Err(), int64(len(found))); err != nil {
+		return nil, readErr(path, PhaseRead, -1, err)
+	}
+	return found, nil
+}
+
+// ReadLinesAsync returns the lines of the file at path like ReadLines,
+// but the chunks are read and split into lines concurrently. The lines
+// crossing a chunk boundary are put back together, so the result is
+// the one of ReadLines with the default split and the same EOL. Lines
+// are not limited to the MaxLineSize.
+func ReadLinesAsync(path string) ([]string, error) {
+	return defaultReader.ReadLinesAsync(path)
+}
+
+// ReadLinesAsync is the package level ReadLinesAsync using r's config.
+func (r *Reader) ReadLinesAsync(path string) ([]string, error) {
+	lines := []string{}
+
+	// the chunks are split at every '\n', the end of line is then
+	// looked at in file order: with EOLCRLF a line ended by a lone '\n'
+	// is held in pending until the "\r\n" which really ends it
+	var pending bytes.Buffer
+	add := func(line []byte, terminated bool) {
+		switch r.cfg.EOL {
+		case EOLLF:
+		case EOLCRLF:
+			if terminated && !bytes.HasSuffix(line, []byte{'\r'}) {
+				pending.Write(line)
+				pending.WriteByte('\n')
+				return
+			}
+			if terminated {
+				line = line[:len(line)-1]
+			}
+			pending.Write(line)
+			line = pending.Bytes()
+			defer pending.Reset()
+		default:
+			line = bytes.TrimSuffix(line, []byte{'\r'})
+		}
+		lines = append(lines, string(r.transform(line)))
+	}
+
+	// but with EOLCRLF, where a line can span several pieces, the
+	// workers finish the lines of their chunks themselves
+	whole := r.cfg.EOL != EOLCRLF
+
+	// every boundary line is followed by a chunk but the last line
+	// of a file which doesn't end with a newline, hold it until then
+	var held []byte
+	holding := false
+
+	err := r.scanLineChunks(path, func(block []byte, start int64) interface{} {
+		var found []string
+		for len(block) > 0 {
+			end := bytes.IndexByte(block, '\n')
+			line := block[:end]
+			if whole {
+				if r.cfg.EOL == EOLAuto {
+					line = bytes.TrimSuffix(line, []byte{'\r'})
+				}
+				line = r.transform(line)
+			}
+			found = append(found, string(line))
+			block = block[end+1:]
+		}
+		return found
+	}, func(line []byte, start int64) {
+		held, holding = append(held[:0], line...), true
+	}, func(result interface{}) {
+		if holding {
+			add(held, true)
+			holding = false
+		}
+		for _, line := range result.([]string) {
+			if whole {
+				lines = append(lines, line)
+				continue
+			}
+			add([]byte(line), true)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if holding {
+		add(held, false)
+	}
+	// with EOLCRLF, the file ended by a lone '\n'
+	if pending.Len() > 0 {
+		lines = append(lines, string(r.transform(pending.Bytes())))
+	}
+	return lines, nil
+}
+
+// lineChunk is a chunk of scanLineChunks.
+type lineChunk struct {
+	offset, end int64
+
+	// what block returned for the complete lines of the chunk
+	result interface{}
+
+	// the bytes before the first newline of the chunk and after
+	// the last one, copied as the chunk buffer is reused. whole
+	// is set when the chunk has no newline, head is all of it.
+	head, tail []byte
+	whole      bool
+}
+
+// scanLineChunks reads the file at path concurrently and calls block,
+// from the workers, with the complete lines of every chunk: the '\n'
+// terminated lines between its first and its last newline, starting at
+// offset start in the file.
+//
+// The chunks are then walked in file order from the calling goroutine.
+// For every chunk, boundary is called with the line crossing the chunk
+// boundary before it, put back together from the pieces of the chunks,
+// then chunk with what block returned. A last line without a newline
+// is passed to boundary at the end.
+func (r *Reader) scanLineChunks(path string, block func(data []byte, start int64) interface{}, boundary func(line []byte, start int64), chunk func(result interface{})) error {
+	var mu sync.Mutex
+	var chunks []lineChunk
+
+	err := r.ScanAsync(path, func(offset int64, data []byte) error {
+		c := lineChunk{offset: offset, end: offset + int64(len(data))}
+
+		first := bytes.IndexByte(data, '\n')
+		if first < 0 {
+			c.head = append([]byte(nil), data...)
+			c.whole = true
+		} else {
+			last := bytes.LastIndexByte(data, '\n')
+			c.head = append([]byte(nil), data[:first]...)
+			c.tail = append([]byte(nil), data[last+1:]...)
+			c.result = block(data[first+1:last+1], offset+int64(first)+1)
+		}
+
+		mu.Lock()
+		chunks = append(chunks, c)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].offset < chunks[j].offset })
+
+	// between the last newline of a chunk and the first newline
+	// of a following one there is exactly one line
+	var carry []byte
+	carryStart := int64(0)
+	for _, c := range chunks {
+		if c.whole {
+			carry = append(carry, c.head...)
+			continue
+		}
+
+		boundary(append(carry, c.head...), carryStart)
+		chunk(c.result)
+
+		carry = c.tail
+		carryStart = c.end - int64(len(c.tail))
+	}
+
+	// the last line, when the file doesn't end with a newline
+	if len(carry) > 0 {
+		boundary(carry, carryStart)
+	}
+	return nil
+}
+
+// CountLines returns the number of lines in the file at path.
+// The chunks are read and counted concurrently, every '\n' ends a line
+// and a last line without a trailing newline is counted too.
+// An empty file has 0 lines.
+func CountLines(path string) (int64, error) {
+	return defaultReader.CountLines(path)
+}
+
+// CountLines is the package level CountLines using r's config.
+func (r *Reader) CountLines(path string) (int64, error) {
+	var lines int64
+
+	// last byte of the file, kept by the chunk ending furthest
+	// as the chunks don't complete in order
+	var mu sync.Mutex
+	var end int64
+	var lastByte byte
+
+	err := r.ScanAsync(path, func(offset int64, data []byte) error {
+		atomic.AddInt64(&lines, int64(bytes.Count(data, []byte{'\n'})))
+
+		if len(data) > 0 {
+			mu.Lock()
+			if e := offset + int64(len(data)); e > end {
+				end = e
+				lastByte = data[len(data)-1]
+			}
+			mu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// the final partial line
+	if end > 0 && lastByte != '\n' {
+		lines++
+	}
+	return lines, nil
+}
+
+// SyncCountLines returns the number of lines in the file at path,
+// counted sequentially by the scanner of ReadSync. It is the simple
+// reference CountLines can be checked against, both agree on every
+// file with the default split.
+func SyncCountLines(path string) (int64, error) {
+	return defaultReader.SyncCountLines(path)
+}
+
+// SyncCountLines is the package level SyncCountLines using r's config.
+func (r *Reader) SyncCountLines(path string) (int64, error) {
+	src, err := r.openSequential(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	_, lines, err := r.syncReadFile(path, src, nil)
+	if err != nil {
+		return 0, err
+	}
+	return lines, nil
+}
+
+// Tail returns the last lines lines of the file at path, or all of
+// them when the file has fewer. The file is read backward from its end
+// a chunk at a time until enough lines were seen, so only the end of
+// the file is read.
+//
+// Lines are split at the configured EOL and don't include their end of line,
+// like ReadLines with the default split. The bytes are read as they
+// are in the file, gzip compressed files are not decompressed.
+func Tail(path string, lines int) ([]string, error) {
+	return defaultReader.Tail(path, lines)
+}
+
+// Tail is the package level Tail using r's config.
+func (r *Reader) Tail(path string, lines int) ([]string, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	if lines <= 0 {
+		return []string{}, nil
+	}
+
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// read chunks backward until the newline before the first wanted
+	// line was seen. The file usually ends with a newline, which ends
+	// the last line instead of starting one, hence lines+1 of them.
+	sep := []byte{'\n'}
+	if r.cfg.EOL == EOLCRLF {
+		sep = crlf
+	}
+
+	var tail []byte
+	newlines := 0
+	pos := fileStats.Size()
+	for pos > 0 && newlines <= lines {
+		length := r.cfg.ChunkSize
+		if length > pos {
+			length = pos
+		}
+		pos -= length
+
+		chunk := make([]byte, length, length+int64(len(tail)))
+		if _, err := io.ReadFull(io.NewSectionReader(file, pos, length), chunk); err != nil {
+			return nil, readErr(path, PhaseRead, pos, err)
+		}
+		newlines += bytes.Count(chunk, sep)
+		// a "\r\n" split between this chunk and the previous one
+		if len(sep) > 1 && bytes.HasSuffix(chunk, sep[:1]) && bytes.HasPrefix(tail, sep[1:]) {
+			newlines++
+		}
+		tail = append(chunk, tail...)
+	}
+
+	if len(tail) == 0 {
+		return []string{}, nil
+	}
+
+	// when we stopped before the start of the file the first
+	// piece is a partial line, which the cut below drops
+	found := strings.Split(string(bytes.TrimSuffix(tail, sep)), string(sep))
+	if len(found) > lines {
+		found = found[len(found)-lines:]
+	}
+	for i, line := range found {
+		if r.cfg.EOL == EOLAuto {
+			line = strings.TrimSuffix(line, "\r")
+		}
+		if r.cfg.Transform != nil {
+			line = string(r.cfg.Transform([]byte(line)))
+		}
+		found[i] = line
+	}
+	return found, nil
+}
@@ -0,0 +1,144 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// scanLines returns the lines of data split by split.
+func scanLines(data []byte, split bufio.SplitFunc) []string {
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	scanner.Split(split)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestReadLinesAsyncChunkBoundaries(t *testing.T) {
+	const cs = 10
+	for _, text := range []string{
+		// a line ending right at the end of a chunk
+		"012345678\nabcdefghi\n",
+		// a '\n' first in a chunk
+		"0123456789\nabcdefghi\n",
+		// a line over several chunks
+		strings.Repeat("x", 3*cs+4) + "\nend",
+		// empty lines at boundaries
+		"\n\n\n\n\n\n\n\n\n\n\n\n",
+		"012345678\n\n\nabc\n",
+		// a "\r\n" split by a boundary
+		"012345678\r\nabcdefgh\r\n",
+		"",
+		"no newline",
+	} {
+		want := scanLines([]byte(text), bufio.ScanLines)
+		lines, err := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: cs}).ReadLinesAsync(writeTmp(t, []byte(text)))
+		if err != nil || !slices.Equal(lines, want) {
+			t.Errorf("ReadLinesAsync(%q) = %q, %v, want %q", text, lines, err, want)
+		}
+	}
+}
+
+func BenchmarkCountLines(b *testing.B) {
+	path := writeTmp(b, lineData(16<<20))
+	r := NewReader(ReaderConfig{SyncThreshold: -1})
+
+	b.SetBytes(16 << 20)
+	for i := 0; i < b.N; i++ {
+		if _, err := r.CountLines(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCountLinesScanner is the bufio.Scanner loop CountLines
+// is meant to beat.
+func BenchmarkCountLinesScanner(b *testing.B) {
+	path := writeTmp(b, lineData(16<<20))
+
+	b.SetBytes(16 << 20)
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var lines int64
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			lines++
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func FuzzReadLinesAsync(f *testing.F) {
+	f.Add([]byte("a\nb\r\nc"), uint8(1))
+	f.Add([]byte("\r\n\n\r\r\n"), uint8(2))
+	f.Add([]byte("line\r"), uint8(4))
+	f.Add([]byte(""), uint8(3))
+
+	f.Fuzz(func(t *testing.T, data []byte, chunkSize uint8) {
+		cs := int64(chunkSize%16) + 1
+		path := writeTmp(t, data)
+		for _, eol := range []EOL{EOLAuto, EOLLF, EOLCRLF} {
+			want := scanLines(data, eol.split())
+			lines, err := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: cs, EOL: eol}).ReadLinesAsync(path)
+			if err != nil || !slices.Equal(lines, want) {
+				t.Errorf("ReadLinesAsync(%q) with EOL %d and %d byte chunks = %q, %v, want %q", data, eol, cs, lines, err, want)
+			}
+		}
+	})
+}
+
+func TestEOL(t *testing.T) {
+	const mixed = "unix\nwindows\r\nlone\rcr\n\r\nlast\r"
+	const crlfOnly = "one\r\ntwo\r\n\r\nthree"
+	for _, tt := range []struct {
+		eol  EOL
+		text string
+		want []string
+	}{
+		{EOLAuto, mixed, []string{"unix", "windows", "lone\rcr", "", "last"}},
+		{EOLLF, mixed, []string{"unix", "windows\r", "lone\rcr", "\r", "last\r"}},
+		{EOLCRLF, mixed, []string{"unix\nwindows", "lone\rcr\n", "last\r"}},
+		{EOLAuto, crlfOnly, []string{"one", "two", "", "three"}},
+		{EOLLF, crlfOnly, []string{"one\r", "two\r", "\r", "three"}},
+		{EOLCRLF, crlfOnly, []string{"one", "two", "", "three"}},
+	} {
+		path := writeTmp(t, []byte(tt.text))
+		r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 3, EOL: tt.eol})
+
+		lines, err := r.ReadLines(path)
+		if err != nil || !slices.Equal(lines, tt.want) {
+			t.Errorf("ReadLines(%q) with EOL %d = %q, %v, want %q", tt.text, tt.eol, lines, err, tt.want)
+		}
+		lines, err = r.ReadLinesAsync(path)
+		if err != nil || !slices.Equal(lines, tt.want) {
+			t.Errorf("ReadLinesAsync(%q) with EOL %d = %q, %v, want %q", tt.text, tt.eol, lines, err, tt.want)
+		}
+		lines, err = r.Head(path, 2)
+		if err != nil || !slices.Equal(lines, tt.want[:2]) {
+			t.Errorf("Head(%q, 2) with EOL %d = %q, %v, want %q", tt.text, tt.eol, lines, err, tt.want[:2])
+		}
+		lines, err = r.Tail(path, 2)
+		if err != nil || !slices.Equal(lines, tt.want[len(tt.want)-2:]) {
+			t.Errorf("Tail(%q, 2) with EOL %d = %q, %v, want %q", tt.text, tt.eol, lines, err, tt.want[len(tt.want)-2:])
+		}
+	}
+
+	if _, err := NewReader(ReaderConfig{EOL: EOLCRLF + 1}).ReadLines(writeTmp(t, nil)); err != ErrInvalidEOL {
+		t.Errorf("ReadLines with an unknown EOL = %v, want ErrInvalidEOL", err)
+	}
+}
@@ -0,0 +1,54 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// errMmapUnsupported is returned by mmapFile on platforms without mmap.
+var errMmapUnsupported = errors.New("filereader: mmap is not supported on this platform")
+
+// mmapReaderAt exposes a memory mapped file as an io.ReaderAt,
+// so the chunk workers read it like any other source.
+type mmapReaderAt struct {
+	data []byte
+}
+
+func (m *mmapReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("filereader: negative offset")
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// source returns the io.ReaderAt the chunks of file are read from with
+// r's strategy: the file itself, or its memory mapping for StrategyMmap.
+// release must be called once the read is done. The returned strategy is
+// the one actually used, mapping the file falls back to StrategyReadAt
+// when it fails. r's Hint is given for the returned source.
+func (r *Reader) source(file *os.File, size int64) (src io.ReaderAt, strategy Strategy, release func()) {
+	if r.cfg.Strategy != StrategyMmap {
+		r.advise(file, file, size)
+		return file, r.cfg.Strategy, func() {}
+	}
+
+	mapped, err := mmapFile(file, size)
+	if err != nil {
+		r.cfg.Logger.Printf("filereader: cannot mmap %s, falling back to ReadAt: %v", file.Name(), err)
+		r.advise(file, file, size)
+		return file, StrategyReadAt, func() {}
+	}
+	r.advise(file, mapped, size)
+	return mapped, StrategyMmap, func() { mapped.Close() }
+}
@@ -0,0 +1,18 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build !unix
+
+package filereader
+
+import "os"
+
+// mmapFile always fails on platforms without mmap,
+// the reader falls back to StrategyReadAt.
+func mmapFile(file *os.File, size int64) (*mmapReaderAt, error) {
+	return nil, errMmapUnsupported
+}
+
+// Close does nothing, nothing is ever mapped.
+func (m *mmapReaderAt) Close() error {
+	return nil
+}
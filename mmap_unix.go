@@ -0,0 +1,43 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build unix
+
+package filereader
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errMmapTooLarge is returned by mmapFile for a file larger than a
+// mapping can be on this platform, more than 2GB on 32-bit ones.
+var errMmapTooLarge = errors.New("filereader: file is too large to be mapped")
+
+// mmapFile maps the first size bytes of file in memory, read only.
+func mmapFile(file *os.File, size int64) (*mmapReaderAt, error) {
+	// mapping 0 bytes is an error, an empty file needs no mapping
+	if size == 0 {
+		return &mmapReaderAt{}, nil
+	}
+
+	if int64(int(size)) != size {
+		return nil, errMmapTooLarge
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &mmapReaderAt{data: data}, nil
+}
+
+// Close unmaps the region.
+func (m *mmapReaderAt) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	data := m.data
+	m.data = nil
+	return syscall.Munmap(data)
+}
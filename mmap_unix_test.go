@@ -0,0 +1,43 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build unix
+
+package filereader
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMmapOverFourGB(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "sparse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// a sparse file, nothing is written
+	const size int64 = 1<<32 + 10
+	if err := file.Truncate(size); err != nil {
+		t.Skip("cannot make a sparse file:", err)
+	}
+
+	mapped, err := mmapFile(file, size)
+	if math.MaxInt == math.MaxInt32 {
+		// mapping int(size) would only map 10 bytes
+		if !errors.Is(err, errMmapTooLarge) {
+			t.Fatalf("mmapFile = %v, want errMmapTooLarge", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Skip("cannot map the file:", err)
+	}
+	defer mapped.Close()
+	if int64(len(mapped.data)) != size {
+		t.Fatalf("mapped %d bytes, want %d", len(mapped.data), size)
+	}
+}
@@ -0,0 +1,113 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"sync"
+)
+
+// ReadAll reads every file of paths with the asynchronous reader and
+// returns their contents keyed by path.
+//
+// Files are read in parallel as well as chunked within each file, but
+// the chunk reads in flight across all the files never exceed the
+// concurrency of a single read.
+//
+// ReadAll fails fast: the first failing file cancels the others and
+// its error is returned with no results. Use ReadAllPartial to get
+// the files which could be read along with the per-file errors.
+func ReadAll(paths []string) (map[string][]byte, error) {
+	return defaultReader.ReadAll(paths)
+}
+
+// ReadAllPartial is like ReadAll but reads every file whatever happens
+// to the others. It returns the contents of the files read successfully
+// and the errors of the others, both keyed by path.
+func ReadAllPartial(paths []string) (map[string][]byte, map[string]error) {
+	return defaultReader.ReadAllPartial(paths)
+}
+
+// ReadAll is the package level ReadAll using r's config.
+func (r *Reader) ReadAll(paths []string) (map[string][]byte, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var firstErr error
+	var once sync.Once
+
+	results, _ := r.readAll(ctx, paths, func(path string, err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// ReadAllPartial is the package level ReadAllPartial using r's config.
+func (r *Reader) ReadAllPartial(paths []string) (map[string][]byte, map[string]error) {
+	return r.readAll(context.Background(), paths, nil)
+}
+
+// readAll reads paths in parallel and calls onErr, when not nil,
+// as soon as a file fails.
+func (r *Reader) readAll(ctx context.Context, paths []string, onErr func(path string, err error)) (map[string][]byte, map[string]error) {
+	// every file gets its own workers, but they all share
+	// one limiter for the total concurrency bound
+	shared := r
+	if shared.limiter == nil {
+		shared = NewReader(r.cfg)
+		shared.limiter = make(chan struct{}, r.concurrency())
+		shared.rate = r.rate
+	}
+
+	var mu sync.Mutex
+	results := make(map[string][]byte, len(paths))
+	errs := make(map[string]error)
+
+	// files read at the same time, no more than the chunk reads
+	// allowed in flight as each file needs at least one.
+	files := make(chan struct{}, r.concurrency())
+
+	var wg sync.WaitGroup
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		// nothing more to start once cancelled
+		if ctx.Err() != nil {
+			break
+		}
+
+		// the same path is only read once
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		wg.Add(1)
+		files <- struct{}{}
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-files }()
+
+			data, _, err := shared.readAsync(ctx, path)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[path] = err
+				if onErr != nil {
+					onErr(path, err)
+				}
+				return
+			}
+			results[path] = data
+		}(path)
+	}
+	wg.Wait()
+
+	return results, errs
+}
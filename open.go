@@ -0,0 +1,210 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotOpen is returned when a Reader which is not bound to a file
+// by Open is used as one.
+var ErrNotOpen = errors.New("filereader: reader is not bound to a file, use Open")
+
+// Open opens the file at path and returns a Reader bound to it,
+// using the default config. The Reader must be closed once done.
+func Open(path string) (*Reader, error) {
+	return defaultReader.Open(path)
+}
+
+// Open is the package level Open, the returned Reader uses r's config.
+func (r *Reader) Open(path string) (*Reader, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bound := NewReader(r.cfg)
+	bound.limiter = r.limiter
+	bound.rate = r.rate
+	bound.file = file
+	bound.fileStats = fileStats
+	return bound, nil
+}
+
+// Close stops the read in progress, if any, and closes the file
+// bound by Open.
+func (r *Reader) Close() error {
+	if r.file == nil {
+		return ErrNotOpen
+	}
+	if r.stream != nil {
+		r.stream.stop()
+	}
+	return r.file.Close()
+}
+
+// Read implements io.Reader: the first call starts reading the bound
+// file concurrently and the bytes are then served in order, with the
+// chunks read ahead of the consumer held in a reorder buffer of a few
+// chunks. It returns io.EOF once the whole file was read.
+//
+// Workers wait when the consumer is slow, and Close stops them.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.file == nil {
+		return 0, ErrNotOpen
+	}
+
+	st := r.readStream()
+	for len(st.cur) == 0 {
+		more, err := st.next()
+		if !more {
+			if err == nil {
+				err = io.EOF
+			}
+			return 0, err
+		}
+	}
+
+	n := copy(p, st.cur)
+	st.cur = st.cur[n:]
+	return n, nil
+}
+
+// WriteTo implements io.WriterTo: it reads the bound file concurrently
+// and writes it to w chunk by chunk, in order, without holding the whole
+// file in memory. It returns the number of bytes written, the size of
+// the file when everything went well. The first error from w stops
+// the read and is returned.
+//
+// The chunks are read concurrently and complete in any order, but w
+// only ever sees them in file order: a chunk read ahead of time waits in
+// a reorder buffer keyed by offset until every byte before it has been
+// written. w.Write is never called concurrently, and the bytes written
+// are the file byte for byte whatever the timing of the reads.
+//
+// WriteTo and Read consume the same stream, so WriteTo after some Read
+// calls writes what is left.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	if r.file == nil {
+		return 0, ErrNotOpen
+	}
+
+	st := r.readStream()
+	var written int64
+	for {
+		if len(st.cur) > 0 {
+			n, err := w.Write(st.cur)
+			written += int64(n)
+			st.cur = st.cur[n:]
+			if err == nil && len(st.cur) > 0 {
+				err = io.ErrShortWrite
+			}
+			if err != nil {
+				st.stop()
+				return written, err
+			}
+		}
+
+		more, err := st.next()
+		if !more {
+			return written, err
+		}
+	}
+}
+
+// readStream returns the stream over the bound file,
+// starting it on the first call.
+func (r *Reader) readStream() *stream {
+	if r.stream != nil {
+		return r.stream
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &stream{
+		cancel:  cancel,
+		chunks:  make(chan []byte),
+		release: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	r.stream = st
+
+	go func() {
+		defer close(st.done)
+		st.err = r.readBound(ctx, func(offset int64, data []byte) error {
+			// the buffer is only valid until we return, so wait for
+			// the consumer to be done with it
+			select {
+			case st.chunks <- data:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			select {
+			case <-st.release:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+	}()
+	return st
+}
+
+// readBound reads the bound file and calls fn with every chunk in order.
+func (r *Reader) readBound(ctx context.Context, fn func(offset int64, data []byte) error) error {
+	return r.scanFile(r.file, r.fileStats, func(src io.ReaderAt, size int64) error {
+		return r.readChunksOrdered(ctx, r.file.Name(), src, size, fn)
+	}, func(src io.Reader) error {
+		return r.readSequential(r.file.Name(), src, fn)
+	})
+}
+
+// stream hands the chunks of readBound, in order, to Read and WriteTo.
+// The producing goroutine blocks until the current chunk is released,
+// which is what keeps the workers from running away from a slow consumer.
+type stream struct {
+	cancel  context.CancelFunc
+	chunks  chan []byte
+	release chan struct{}
+	done    chan struct{}
+	err     error // set before done is closed
+
+	cur  []byte // unread part of the current chunk
+	held bool   // the producer waits for the current chunk to be released
+}
+
+// next releases the current chunk and waits for the following one.
+// It returns false and the read error, nil at the end of the file,
+// once there are no chunks left.
+func (st *stream) next() (bool, error) {
+	if st.held {
+		st.held = false
+		select {
+		case st.release <- struct{}{}:
+		case <-st.done:
+		}
+	}
+
+	select {
+	case data := <-st.chunks:
+		st.cur = data
+		st.held = true
+		return true, nil
+	case <-st.done:
+		st.cur = nil
+		return false, st.err
+	}
+}
+
+// stop cancels the read and waits for its goroutines to be gone.
+func (st *stream) stop() {
+	st.cancel()
+	<-st.done
+	st.cur = nil
+	st.held = false
+}
@@ -0,0 +1,68 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jitterReaderAt reads from src after a random delay, so the chunks
+// complete in any order.
+type jitterReaderAt struct {
+	src io.ReaderAt
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (j *jitterReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	j.mu.Lock()
+	delay := time.Duration(j.rnd.Intn(500)) * time.Microsecond
+	j.mu.Unlock()
+	time.Sleep(delay)
+	return j.src.ReadAt(p, off)
+}
+
+func TestReadChunksOrderedJitter(t *testing.T) {
+	data := randData(200*100 + 37)
+	r := NewReader(ReaderConfig{ChunkSize: 100, Concurrency: 16})
+
+	for run := 0; run < 20; run++ {
+		src := &jitterReaderAt{src: bytes.NewReader(data), rnd: rand.New(rand.NewSource(int64(run)))}
+		var out bytes.Buffer
+		err := r.readChunksOrdered(context.Background(), "", src, int64(len(data)), func(offset int64, chunk []byte) error {
+			if offset != int64(out.Len()) {
+				t.Fatalf("run %d: chunk at %d after %d bytes", run, offset, out.Len())
+			}
+			out.Write(chunk)
+			return nil
+		})
+		if err != nil || !bytes.Equal(out.Bytes(), data) {
+			t.Fatalf("run %d: wrote %d bytes differing from the input, %v", run, out.Len(), err)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	data := randData(200*100 + 37)
+	path := writeTmp(t, data)
+
+	for run := 0; run < 20; run++ {
+		bound, err := NewReader(ReaderConfig{ChunkSize: 100, Concurrency: 16}).Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		n, err := bound.WriteTo(&out)
+		bound.Close()
+		if err != nil || n != int64(len(data)) || !bytes.Equal(out.Bytes(), data) {
+			t.Fatalf("run %d: WriteTo = %d, %v, differing from the input", run, n, err)
+		}
+	}
+}
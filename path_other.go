@@ -0,0 +1,10 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build !windows
+
+package filereader
+
+// longPath returns path as it is, only windows limits the length of paths.
+func longPath(path string) string {
+	return path
+}
@@ -0,0 +1,38 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build windows
+
+package filereader
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// paths from this length on may hit the MAX_PATH limit of 260
+// characters once windows adds a file name to a directory
+const maxShortPath = 248
+
+// longPath returns path in the \\?\ form windows needs to open paths
+// longer than MAX_PATH, \\?\UNC\server\share\... for UNC paths. The
+// prefix turns off the normalization done by windows, so the path is
+// made absolute and cleaned first. Short paths are kept as they are.
+//
+// The os package prefixes long paths itself, but the relative ones only
+// from go1.23 on, and go.mod allows go1.22. UNC paths are left alone
+// when short, like os does.
+func longPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) < maxShortPath {
+		return path
+	}
+
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
@@ -0,0 +1,49 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+//go:build windows
+
+package filereader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	short := filepath.Join(t.TempDir(), "f")
+	if got := longPath(short); got != short {
+		t.Errorf("longPath(%q) = %q, want it unchanged", short, got)
+	}
+	if got := longPath(`\\server\share\f`); got != `\\server\share\f` {
+		t.Errorf("short UNC path changed to %q", got)
+	}
+	if got := longPath(`\\?\C:\f`); got != `\\?\C:\f` {
+		t.Errorf("prefixed path changed to %q", got)
+	}
+
+	long := `\\server\share\` + strings.Repeat("d", 300)
+	if got := longPath(long); !strings.HasPrefix(got, `\\?\UNC\server\share\`) {
+		t.Errorf("long UNC path gave %q", got)
+	}
+}
+
+func TestReadAsyncLongPath(t *testing.T) {
+	dir := t.TempDir()
+	for len(dir) < 300 {
+		dir = filepath.Join(dir, strings.Repeat("d", 50))
+	}
+	if err := os.MkdirAll(longPath(dir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "f")
+	if err := os.WriteFile(longPath(path), []byte("long"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadAsync(path)
+	if err != nil || string(got) != "long" {
+		t.Fatalf("ReadAsync = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,102 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"os"
+)
+
+// ReadPlan is how ReadAsync would read a file.
+type ReadPlan struct {
+	// FileSize is the size of the file in bytes.
+	FileSize int64
+
+	// ChunkSize is the number of bytes of every chunk,
+	// the first and the last ones may be shorter.
+	ChunkSize int64
+
+	// ChunkCount is the number of chunks, len(Offsets).
+	ChunkCount int
+
+	// Concurrency is the number of workers which would read the chunks.
+	Concurrency int
+
+	// Offsets is the offset at which every chunk starts.
+	Offsets []int64
+
+	// Strategy is how the file would be read. A file below the
+	// SyncThreshold is read sequentially, as a single chunk.
+	Strategy Strategy
+}
+
+// Plan returns how ReadAsync would split the file at path into chunks,
+// without reading it. Gzip compression is not detected, as it needs
+// a read, compressed files are always read sequentially. StrategyAdaptive
+// only picks its chunk size while reading, its plan uses ChunkSize.
+func Plan(path string) (ReadPlan, error) {
+	return defaultReader.Plan(path)
+}
+
+// Plan is the package level Plan using r's config.
+func (r *Reader) Plan(path string) (ReadPlan, error) {
+	if err := r.validate(); err != nil {
+		return ReadPlan{}, err
+	}
+
+	if path == "" {
+		return ReadPlan{}, readErr(path, PhaseOpen, -1, ErrEmptyPath)
+	}
+	fileStats, err := os.Stat(longPath(path))
+	if err != nil {
+		return ReadPlan{}, readErr(path, PhaseStat, -1, err)
+	}
+	if fileStats.IsDir() {
+		return ReadPlan{}, readErr(path, PhaseOpen, -1, ErrIsDirectory)
+	}
+	size := fileStats.Size()
+
+	plan := ReadPlan{
+		FileSize:  size,
+		ChunkSize: r.cfg.ChunkSize,
+		Strategy:  r.cfg.Strategy,
+	}
+
+	// same decision as asyncRead
+	if r.cfg.Strategy == StrategySequential || size < r.cfg.SyncThreshold {
+		plan.Strategy = StrategySequential
+		plan.Offsets = []int64{}
+		if size > 0 {
+			plan.Offsets = []int64{0}
+		}
+		plan.ChunkCount = len(plan.Offsets)
+		plan.Concurrency = 1
+		return plan, nil
+	}
+
+	plan.Offsets = r.chunkOffsets(0, size)
+	plan.ChunkCount = len(plan.Offsets)
+
+	plan.Concurrency = r.concurrency()
+	if plan.Concurrency > plan.ChunkCount {
+		plan.Concurrency = plan.ChunkCount
+	}
+	return plan, nil
+}
+
+// ChunksFor returns the number of chunks of chunkSize bytes size bytes
+// are split into, the last one holding the remainder: 0 for an empty
+// size, 1 up to chunkSize bytes, 2 for one byte more. It is the count
+// of ReadAsync without AlignTo, for sizing buffers alike. A chunkSize
+// below 1 gives 0.
+func ChunksFor(size, chunkSize int64) int {
+	if size <= 0 || chunkSize <= 0 {
+		return 0
+	}
+
+	count := size / chunkSize
+	// check for any left over bytes. Add one more chunk if required.
+	if size%chunkSize != 0 {
+		count++
+	}
+	return int(count)
+}
@@ -0,0 +1,52 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChunksFor(t *testing.T) {
+	for _, tt := range []struct {
+		size, chunkSize int64
+		want            int
+	}{
+		{0, 10, 0},
+		{-1, 10, 0},
+		{10, 0, 0},
+		{10, -1, 0},
+		{1, 1, 1},
+		{1, 10, 1},
+		{9, 10, 1},
+		{10, 10, 1},
+		{11, 10, 2},
+		{19, 10, 2},
+		{20, 10, 2},
+		{21, 10, 3},
+		{1000, 1, 1000},
+		{asyncChunkSize, asyncChunkSize, 1},
+		{asyncChunkSize + 1, asyncChunkSize, 2},
+		{math.MaxInt64, math.MaxInt64, 1},
+		{math.MaxInt64, 1 << 62, 2},
+	} {
+		if got := ChunksFor(tt.size, tt.chunkSize); got != tt.want {
+			t.Errorf("ChunksFor(%d, %d) = %d, want %d", tt.size, tt.chunkSize, got, tt.want)
+		}
+	}
+
+	// every size against the chunk grid of the reads, which
+	// stops at the first offset past the end
+	for chunkSize := int64(1); chunkSize <= 17; chunkSize++ {
+		r := NewReader(ReaderConfig{ChunkSize: chunkSize})
+		for size := int64(0); size <= 100; size++ {
+			want := 0
+			for offset := int64(0); offset < size; offset += chunkSize {
+				want++
+			}
+			if got := ChunksFor(size, chunkSize); got != want || r.chunkGrid(0, size).count != want {
+				t.Errorf("ChunksFor(%d, %d) = %d and the grid has %d chunks, want %d", size, chunkSize, got, r.chunkGrid(0, size).count, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import "runtime"
+
+// ReaderPool hands out Readers sharing one bound on the chunk reads in
+// flight, for a server running many reads at the same time: each read
+// still gets the workers of its Concurrency, but all the reads of the
+// pool's Readers together never have more than the pool's concurrency
+// ReadAt calls in flight, so they don't oversubscribe the disk.
+//
+// A worker holds its slot for the time of the ReadAt only, the reads
+// take turns chunk by chunk. A ReadAt abandoned by ChunkTimeout keeps
+// its slot until it returns, so hung ReadAt calls shrink the pool
+// rather than pile up on the disk. A ReaderPool is safe for concurrent
+// use.
+type ReaderPool struct {
+	limiter chan struct{}
+}
+
+// NewReaderPool returns a ReaderPool allowing maxConcurrency chunk
+// reads in flight, runtime.GOMAXPROCS(0) when it is below 1.
+func NewReaderPool(maxConcurrency int) *ReaderPool {
+	if maxConcurrency < 1 {
+		maxConcurrency = runtime.GOMAXPROCS(0)
+	}
+	return &ReaderPool{limiter: make(chan struct{}, maxConcurrency)}
+}
+
+// NewReader is like the package level NewReader, but the returned
+// Reader shares the bound of the pool with the other Readers of the
+// pool, and with the ones returned by its Open.
+func (p *ReaderPool) NewReader(cfg ReaderConfig) *Reader {
+	r := NewReader(cfg)
+	r.limiter = p.limiter
+	return r
+}
@@ -0,0 +1,65 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hungReaderAt blocks every ReadAt until unblock is closed.
+type hungReaderAt struct {
+	unblock chan struct{}
+}
+
+func (h hungReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	<-h.unblock
+	return len(p), nil
+}
+
+// countingReaderAt counts its ReadAt calls.
+type countingReaderAt struct {
+	calls int64 // first, atomic needs it 64-bit aligned on 386 and arm
+	*bytes.Reader
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	atomic.AddInt64(&c.calls, 1)
+	return c.Reader.ReadAt(p, off)
+}
+
+func TestPoolHoldsSlotOfAbandonedRead(t *testing.T) {
+	pool := NewReaderPool(1)
+	hung := hungReaderAt{unblock: make(chan struct{})}
+	defer close(hung.unblock)
+
+	timed := pool.NewReader(ReaderConfig{ChunkTimeout: 20 * time.Millisecond, SyncThreshold: -1})
+	if _, err := timed.ReadAsyncFrom(hung, 100); !errors.Is(err, ErrChunkTimeout) {
+		t.Fatalf("ReadAsyncFrom = %v, want ErrChunkTimeout", err)
+	}
+
+	// the abandoned ReadAt still holds the only slot
+	src := &countingReaderAt{Reader: bytes.NewReader([]byte("data"))}
+	done := make(chan error, 1)
+	go func() {
+		_, err := pool.NewReader(ReaderConfig{SyncThreshold: -1}).ReadAsyncFrom(src, 4)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if calls := atomic.LoadInt64(&src.calls); calls != 0 {
+		t.Fatalf("%d reads ran next to the abandoned one", calls)
+	}
+
+	hung.unblock <- struct{}{}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the read never got the slot back")
+	}
+}
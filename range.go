@@ -0,0 +1,93 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidRange is returned by ReadRange when the range is not
+// within the file.
+var ErrInvalidRange = errors.New("filereader: range is outside of the file")
+
+// ReadRange reads the length bytes of the file at path starting at
+// start, concurrently in chunks like ReadAsync. start+length must not
+// be past the end of the file.
+//
+// The bytes are read as they are in the file, gzip compressed files
+// are not decompressed.
+func ReadRange(path string, start, length int64) ([]byte, error) {
+	return defaultReader.ReadRange(path, start, length)
+}
+
+// ReadRange is the package level ReadRange using r's config.
+func (r *Reader) ReadRange(path string, start, length int64) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// start+length could overflow, compare length to what
+	// is left of the file after start instead
+	size := fileStats.Size()
+	if start < 0 || length < 0 || start > size || length > size-start {
+		err := fmt.Errorf("%w: %d bytes at %d of %d bytes", ErrInvalidRange, length, start, size)
+		return nil, readErr(path, PhaseStat, -1, err)
+	}
+
+	// the section reader shifts the chunk offsets by start
+	data, _, err := r.asyncRead(context.Background(), path, io.NewSectionReader(file, start, length), start, length, nil)
+	return data, err
+}
+
+// ErrTruncated is returned by ReadSince when the file is smaller than
+// it was, its data since the previous read is unknown and it has to be
+// read again from the start.
+var ErrTruncated = errors.New("filereader: file was truncated, read it again from the start")
+
+// ReadSince returns the bytes appended to the file at path since it was
+// prevSize bytes long, read concurrently like ReadRange, and its size
+// now, to pass as prevSize to the next call. It is the read of a log
+// follower. A file smaller than prevSize, truncated or replaced, fails
+// with ErrTruncated; the returned size is then the new one.
+func ReadSince(path string, prevSize int64) ([]byte, int64, error) {
+	return defaultReader.ReadSince(path, prevSize)
+}
+
+// ReadSince is the package level ReadSince using r's config.
+func (r *Reader) ReadSince(path string, prevSize int64) ([]byte, int64, error) {
+	if err := r.validate(); err != nil {
+		return nil, 0, err
+	}
+	if prevSize < 0 {
+		err := fmt.Errorf("%w: negative previous size %d", ErrInvalidRange, prevSize)
+		return nil, 0, readErr(path, PhaseStat, -1, err)
+	}
+
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	size := fileStats.Size()
+	if size < prevSize {
+		err := fmt.Errorf("%w: %d bytes, %d before", ErrTruncated, size, prevSize)
+		return nil, size, readErr(path, PhaseStat, -1, err)
+	}
+
+	// the bytes written after the stat are left for the next call
+	data, _, err := r.asyncRead(context.Background(), path, io.NewSectionReader(file, prevSize, size-prevSize), prevSize, size-prevSize, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, size, nil
+}
@@ -0,0 +1,55 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestReadRange(t *testing.T) {
+	data := randData(10*1000 + 7)
+	path := writeTmp(t, data)
+	size := int64(len(data))
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000})
+
+	for _, tt := range []struct {
+		start, length int64
+		valid         bool
+	}{
+		{0, size, true},
+		{0, 0, true},
+		{size, 0, true},
+		{0, 1, true},
+		{size - 1, 1, true},
+		{999, 1002, true},
+		{1234, 5678, true},
+		// past the end of the file
+		{0, size + 1, false},
+		{size - 1, 2, false},
+		{size, 1, false},
+		{size + 1, 0, false},
+		// negative values
+		{-1, 10, false},
+		{0, -1, false},
+		{-1, -1, false},
+		// start+length overflowing
+		{1, math.MaxInt64, false},
+		{math.MaxInt64, 1, false},
+		{math.MaxInt64, math.MaxInt64, false},
+	} {
+		got, err := r.ReadRange(path, tt.start, tt.length)
+		if tt.valid {
+			if err != nil || !bytes.Equal(got, data[tt.start:tt.start+tt.length]) {
+				t.Errorf("ReadRange(%d, %d) = %d bytes, %v", tt.start, tt.length, len(got), err)
+			}
+			continue
+		}
+		var re *ReadError
+		if !errors.Is(err, ErrInvalidRange) || !errors.As(err, &re) || re.Path != path {
+			t.Errorf("ReadRange(%d, %d) = %v, want a ReadError with ErrInvalidRange", tt.start, tt.length, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ErrEmptyNeedle is returned by Search when the needle is empty.
+var ErrEmptyNeedle = errors.New("filereader: search needle is empty")
+
+// Search returns the byte offsets of every occurrence of needle in the
+// file at path, sorted ascending. Overlapping occurrences are all reported.
+//
+// Every worker searches its own chunk, reading len(needle)-1 bytes past
+// its end so occurrences straddling two chunks are not missed.
+func Search(path string, needle []byte) ([]int64, error) {
+	return defaultReader.Search(path, needle)
+}
+
+// Search is the package level Search using r's config.
+func (r *Reader) Search(path string, needle []byte) ([]int64, error) {
+	if len(needle) == 0 {
+		return nil, ErrEmptyNeedle
+	}
+
+	overlap := int64(len(needle) - 1)
+
+	var mu sync.Mutex
+	matches := []int64{}
+
+	err := r.scan(path, func(src io.ReaderAt, size int64) error {
+		// chunk buffers are bigger than r's pooled ones because of the overlap
+		buffers := sync.Pool{New: func() interface{} {
+			buf := make([]byte, r.cfg.ChunkSize+overlap)
+			return &buf
+		}}
+
+		hooks := chunkHooks{
+			buffer: func(offset, length int64) []byte {
+				buf := buffers.Get().(*[]byte)
+				return (*buf)[:length]
+			},
+			handle: func(offset int64, data []byte) error {
+				defer buffers.Put(&data)
+
+				// occurrences starting in the overlap belong to the next
+				// chunk, which reports them
+				own := chunkLength(size, r.cfg.ChunkSize, offset)
+
+				found := indexAll(data, needle, own)
+
+				mu.Lock()
+				for _, i := range found {
+					matches = append(matches, offset+i)
+				}
+				mu.Unlock()
+				return nil
+			},
+			overlap: overlap,
+			path:    path,
+		}
+
+		_, err := r.readChunks(context.Background(), src, size, hooks)
+		return err
+	}, func(src io.Reader) error {
+		// chunks come in order, so carry the last len(needle)-1 bytes
+		// of a chunk over to the next one instead of overlapping reads
+		var carry []byte
+		return r.readSequential(path, src, func(offset int64, data []byte) error {
+			window := append(carry, data...)
+			start := offset - int64(len(carry))
+
+			for _, i := range indexAll(window, needle, int64(len(window))) {
+				matches = append(matches, start+i)
+			}
+
+			keep := len(window)
+			if keep > int(overlap) {
+				keep = int(overlap)
+			}
+			carry = append(carry[:0], window[len(window)-keep:]...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i] < matches[j] })
+	return matches, nil
+}
+
+// indexAll returns the positions of every occurrence of needle in data
+// starting before limit.
+func indexAll(data, needle []byte, limit int64) []int64 {
+	var found []int64
+	for pos := 0; int64(pos) < limit; pos++ {
+		i := bytes.Index(data[pos:], needle)
+		if i < 0 || int64(pos+i) >= limit {
+			break
+		}
+		pos += i
+		found = append(found, int64(pos))
+	}
+	return found
+}
@@ -0,0 +1,45 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// naiveSearch returns the offsets of every, possibly overlapping,
+// occurrence of needle in data.
+func naiveSearch(data, needle []byte) []int64 {
+	offsets := []int64{}
+	for i := 0; i+len(needle) <= len(data); i++ {
+		if bytes.Equal(data[i:i+len(needle)], needle) {
+			offsets = append(offsets, int64(i))
+		}
+	}
+	return offsets
+}
+
+func TestSearchAcrossChunks(t *testing.T) {
+	const chunkSize = 100
+	data := bytes.Repeat([]byte("-"), 10*chunkSize)
+	// one needle at the start of a chunk, one ending a chunk, two
+	// across a boundary, and overlapping ones across another
+	copy(data[chunkSize:], "needle")
+	copy(data[2*chunkSize-6:], "needle")
+	copy(data[3*chunkSize-1:], "needle")
+	copy(data[5*chunkSize-3:], "needle")
+	copy(data[7*chunkSize-4:], "aaaaaaaa")
+	path := writeTmp(t, data)
+
+	for _, needle := range []string{"needle", "aaa", "e-"} {
+		want := naiveSearch(data, []byte(needle))
+		for _, cs := range []int64{1, 3, 7, chunkSize} {
+			r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: cs})
+			got, err := r.Search(path, []byte(needle))
+			if err != nil || !slices.Equal(got, want) {
+				t.Errorf("Search(%q) with %d byte chunks = %v, %v, want %v", needle, cs, got, err, want)
+			}
+		}
+	}
+}
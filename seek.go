@@ -0,0 +1,220 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrClosed is returned when a SeekReader is used after Close.
+var ErrClosed = errors.New("filereader: reader is closed")
+
+// ErrInvalidSeek is returned by SeekReader.Seek for an unknown whence
+// or a position before the start of the file.
+var ErrInvalidSeek = errors.New("filereader: invalid seek")
+
+// SeekReader is an io.ReadSeeker over a file which reads the chunks
+// after the current position ahead of time, concurrently, for readers
+// jumping around a file such as viewers. Seeking keeps the chunks read
+// ahead of the new position, and drops the other ones, stopping their
+// reads still in flight.
+//
+// A SeekReader is not safe for concurrent use.
+type SeekReader struct {
+	r    *Reader
+	file *os.File
+	size int64
+	pos  int64
+
+	// the chunks read or being read ahead, by index, and
+	// the context of their reads, cancelled by Close
+	chunks map[int64]*prefetched
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// prefetched is a chunk read ahead by a SeekReader, data and err are
+// set once done is closed. cancel stops its read.
+type prefetched struct {
+	done   chan struct{}
+	data   []byte
+	err    error
+	cancel context.CancelFunc
+}
+
+// NewSeekReader returns a SeekReader over f, a file the caller opened,
+// using the default config. The chunks are read with ReadAt, f's offset
+// is left alone. Close the SeekReader before closing f.
+func NewSeekReader(f *os.File) (*SeekReader, error) {
+	return defaultReader.NewSeekReader(f)
+}
+
+// NewSeekReader is the package level NewSeekReader using r's config.
+// ReadAheadChunks chunks are read ahead of the position.
+func (r *Reader) NewSeekReader(f *os.File) (*SeekReader, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	fileStats, err := f.Stat()
+	if err != nil {
+		return nil, readErr(f.Name(), PhaseStat, -1, err)
+	}
+	if fileStats.IsDir() {
+		return nil, readErr(f.Name(), PhaseOpen, -1, ErrIsDirectory)
+	}
+
+	s := &SeekReader{
+		r:      r,
+		file:   f,
+		size:   fileStats.Size(),
+		chunks: make(map[int64]*prefetched),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+// Read implements io.Reader, reading from the current position.
+func (s *SeekReader) Read(p []byte) (int, error) {
+	if s.chunks == nil {
+		return 0, ErrClosed
+	}
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	chunkSize := s.r.cfg.ChunkSize
+	index := s.pos / chunkSize
+	s.prefetch(index)
+
+	c := s.chunks[index]
+	<-c.done
+	if c.err != nil {
+		// read it again on the next call
+		s.drop(index)
+		return 0, c.err
+	}
+
+	n := copy(p, c.data[s.pos-index*chunkSize:])
+	s.pos += int64(n)
+	return n, nil
+}
+
+// Seek implements io.Seeker. Seeking past the end is allowed, Read then
+// returns io.EOF. Moving the position drops the chunks read ahead but
+// the ones from the chunk of the new position on which would be read
+// ahead from there anyway.
+func (s *SeekReader) Seek(offset int64, whence int) (int64, error) {
+	if s.chunks == nil {
+		return 0, ErrClosed
+	}
+
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = s.pos + offset
+	case io.SeekEnd:
+		pos = s.size + offset
+	default:
+		return 0, fmt.Errorf("%w: whence %d", ErrInvalidSeek, whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("%w: position %d", ErrInvalidSeek, pos)
+	}
+
+	// Seek(0, io.SeekCurrent) only asks for the position
+	if pos != s.pos {
+		index := pos / s.r.cfg.ChunkSize
+		last := index + int64(s.r.cfg.ReadAheadChunks)
+		for i := range s.chunks {
+			if i < index || i > last {
+				s.drop(i)
+			}
+		}
+	}
+	s.pos = pos
+	return pos, nil
+}
+
+// Close stops the reads ahead and waits for them. It doesn't close
+// the file.
+func (s *SeekReader) Close() error {
+	if s.chunks == nil {
+		return ErrClosed
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.chunks = nil
+	return nil
+}
+
+// prefetch drops the chunks before index, already consumed, and starts
+// reading the chunk at index and the ReadAheadChunks after it when they
+// are not read yet.
+func (s *SeekReader) prefetch(index int64) {
+	for i := range s.chunks {
+		if i < index {
+			s.drop(i)
+		}
+	}
+
+	chunkSize := s.r.cfg.ChunkSize
+	last := index + int64(s.r.cfg.ReadAheadChunks)
+	for i := index; i <= last && i*chunkSize < s.size; i++ {
+		if _, ok := s.chunks[i]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(s.ctx)
+		c := &prefetched{done: make(chan struct{}), cancel: cancel}
+		s.chunks[i] = c
+
+		offset := i * chunkSize
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer close(c.done)
+			c.data, c.err = s.readChunk(ctx, offset)
+		}()
+	}
+}
+
+// drop forgets the chunk at index, and stops its read if still
+// in flight.
+func (s *SeekReader) drop(index int64) {
+	s.chunks[index].cancel()
+	delete(s.chunks, index)
+}
+
+// readChunk reads the chunk at offset with the chunk reader, which
+// brings the retries, timeouts and limits of r's config along.
+func (s *SeekReader) readChunk(ctx context.Context, offset int64) ([]byte, error) {
+	length := s.r.cfg.ChunkSize
+	if rest := s.size - offset; rest < length {
+		length = rest
+	}
+
+	data := make([]byte, length)
+	hooks := chunkHooks{
+		buffer: func(offset, length int64) []byte {
+			return data[offset : offset+length]
+		},
+		base: offset,
+		path: s.file.Name(),
+	}
+	if _, err := s.r.readChunks(ctx, io.NewSectionReader(s.file, offset, length), length, hooks); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
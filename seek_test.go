@@ -0,0 +1,223 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// openSeekReader returns a SeekReader of r over a file holding data.
+func openSeekReader(t *testing.T, r *Reader, data []byte) *SeekReader {
+	t.Helper()
+	file, err := os.Open(writeTmp(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	s, err := r.NewSeekReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// readAt seeks s to offset and reads n bytes there.
+func readAt(t *testing.T, s *SeekReader, offset int64, n int) []byte {
+	t.Helper()
+	if pos, err := s.Seek(offset, io.SeekStart); err != nil || pos != offset {
+		t.Fatalf("Seek(%d) = %d, %v", offset, pos, err)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s, buf); err != nil {
+		t.Fatalf("read of %d bytes at %d: %v", n, offset, err)
+	}
+	return buf
+}
+
+func TestSeekReaderSequential(t *testing.T) {
+	data := randData(10*100 + 37)
+	s := openSeekReader(t, NewReader(ReaderConfig{ChunkSize: 100, ReadAheadChunks: 2}), data)
+	defer s.Close()
+
+	// reads of 7 bytes cross the chunk boundaries all the time
+	var got bytes.Buffer
+	if _, err := io.CopyBuffer(&got, struct{ io.Reader }{s}, make([]byte, 7)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Fatalf("read %d bytes differing from the %d of the file", got.Len(), len(data))
+	}
+
+	if _, err := s.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if err := iotest.TestReader(s, data); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSeekReaderSeek(t *testing.T) {
+	data := randData(10*100 + 37)
+	size := int64(len(data))
+	s := openSeekReader(t, NewReader(ReaderConfig{ChunkSize: 100}), data)
+	defer s.Close()
+
+	for _, tt := range []struct {
+		offset int64
+		whence int
+		want   int64
+	}{
+		{150, io.SeekStart, 150},
+		{20, io.SeekCurrent, 170},
+		{-70, io.SeekCurrent, 100},
+		{0, io.SeekCurrent, 100},
+		{-37, io.SeekEnd, size - 37},
+		{0, io.SeekEnd, size},
+		{10, io.SeekEnd, size + 10},
+		{0, io.SeekStart, 0},
+	} {
+		pos, err := s.Seek(tt.offset, tt.whence)
+		if err != nil || pos != tt.want {
+			t.Fatalf("Seek(%d, %d) = %d, %v, want %d", tt.offset, tt.whence, pos, err, tt.want)
+		}
+		if tt.want >= size {
+			continue
+		}
+		buf := make([]byte, 10)
+		n, err := io.ReadFull(s, buf)
+		if err != nil && err != io.ErrUnexpectedEOF || !bytes.Equal(buf[:n], data[tt.want:tt.want+int64(n)]) {
+			t.Fatalf("read after Seek(%d, %d) = %d bytes, %v", tt.offset, tt.whence, n, err)
+		}
+		// back to the position of the seek for the next one
+		if _, err := s.Seek(tt.want, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		offset int64
+		whence int
+	}{
+		{-1, io.SeekStart},
+		{-1, io.SeekCurrent},
+		{-size - 1, io.SeekEnd},
+		{0, 42},
+	} {
+		if _, err := s.Seek(tt.offset, tt.whence); !errors.Is(err, ErrInvalidSeek) {
+			t.Errorf("Seek(%d, %d) = %v, want ErrInvalidSeek", tt.offset, tt.whence, err)
+		}
+	}
+}
+
+func TestSeekReaderSeekDuringPrefetch(t *testing.T) {
+	data := randData(20*100 + 37)
+	// the bucket holds a chunk, the ones read ahead come every 10ms
+	r := NewReader(ReaderConfig{ChunkSize: 100, ReadAheadChunks: 4, MaxBytesPerSec: 10 * 1000})
+	s := openSeekReader(t, r, data)
+	defer s.Close()
+
+	// chunks 1 to 4 are read ahead of the first
+	if got := readAt(t, s, 10, 10); !bytes.Equal(got, data[10:20]) {
+		t.Fatal("wrong bytes at 10")
+	}
+	kept := s.chunks[3]
+
+	// forward, into a chunk read ahead: it and the ones after it stay
+	if _, err := s.Seek(320, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if s.chunks[3] != kept || s.chunks[1] != nil || s.chunks[2] != nil {
+		t.Fatalf("Seek into chunk 3 kept the chunks %v", s.chunks)
+	}
+	if got := readAt(t, s, 320, 150); !bytes.Equal(got, data[320:470]) {
+		t.Fatal("wrong bytes at 320")
+	}
+
+	// backwards, then far forwards while reads are in flight
+	if got := readAt(t, s, 5, 300); !bytes.Equal(got, data[5:305]) {
+		t.Fatal("wrong bytes at 5")
+	}
+	if got := readAt(t, s, 1950, 87); !bytes.Equal(got, data[1950:]) {
+		t.Fatal("wrong bytes at 1950")
+	}
+	for i := range s.chunks {
+		if i < 19 {
+			t.Errorf("chunk %d still read ahead at the end of the file", i)
+		}
+	}
+}
+
+func TestSeekReaderEOF(t *testing.T) {
+	data := randData(3*100 + 37)
+	size := int64(len(data))
+	s := openSeekReader(t, NewReader(ReaderConfig{ChunkSize: 100}), data)
+	defer s.Close()
+
+	// a read over the end stops at it
+	buf := make([]byte, 100)
+	if _, err := s.Seek(size-10, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.Read(buf)
+	if err != nil || n != 10 || !bytes.Equal(buf[:n], data[size-10:]) {
+		t.Fatalf("Read of the last bytes = %d, %v", n, err)
+	}
+	if n, err := s.Read(buf); n != 0 || err != io.EOF {
+		t.Fatalf("Read at the end = %d, %v, want io.EOF", n, err)
+	}
+
+	if _, err := s.Seek(size+100, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.Read(buf); n != 0 || err != io.EOF {
+		t.Fatalf("Read past the end = %d, %v, want io.EOF", n, err)
+	}
+
+	// an empty file is at its end right away
+	empty := openSeekReader(t, NewReader(ReaderConfig{}), nil)
+	defer empty.Close()
+	if n, err := empty.Read(buf); n != 0 || err != io.EOF {
+		t.Fatalf("Read of an empty file = %d, %v, want io.EOF", n, err)
+	}
+}
+
+func TestSeekReaderCloseDuringPrefetch(t *testing.T) {
+	data := randData(20 * 100)
+	r := NewReader(ReaderConfig{ChunkSize: 100, ReadAheadChunks: 8, MaxBytesPerSec: 1000})
+	before := runtime.NumGoroutine()
+
+	s := openSeekReader(t, r, data)
+	// the first chunk is in the bucket, the 8 read ahead wait for it
+	if got := readAt(t, s, 0, 10); !bytes.Equal(got, data[:10]) {
+		t.Fatal("wrong bytes at 0")
+	}
+
+	start := time.Now()
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close waited %v for the reads ahead, instead of stopping them", elapsed)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines after Close, %d before", after, before)
+	}
+
+	if _, err := s.Read(make([]byte, 1)); err != ErrClosed {
+		t.Errorf("Read after Close = %v, want ErrClosed", err)
+	}
+	if _, err := s.Seek(0, io.SeekStart); err != ErrClosed {
+		t.Errorf("Seek after Close = %v, want ErrClosed", err)
+	}
+	if err := s.Close(); err != ErrClosed {
+		t.Errorf("Close after Close = %v, want ErrClosed", err)
+	}
+}
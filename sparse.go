@@ -0,0 +1,60 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// Range is a run of bytes of a file.
+type Range struct {
+	Offset int64
+	Length int64
+}
+
+// ZeroRanges scans the file at path concurrently and returns the ranges
+// made of zero bytes only, sorted by offset, typically the holes of
+// a sparse file. Zero runs are only detected a whole chunk at a time:
+// a range always starts and ends on chunk boundaries (or at the end of
+// the file), adjacent zero chunks being merged into one range.
+func ZeroRanges(path string) ([]Range, error) {
+	return defaultReader.ZeroRanges(path)
+}
+
+// ZeroRanges is the package level ZeroRanges using r's config.
+func (r *Reader) ZeroRanges(path string) ([]Range, error) {
+	zeros := make([]byte, r.cfg.ChunkSize)
+
+	var mu sync.Mutex
+	found := []Range{}
+
+	err := r.ScanAsync(path, func(offset int64, data []byte) error {
+		if !bytes.Equal(data, zeros[:len(data)]) {
+			return nil
+		}
+
+		mu.Lock()
+		found = append(found, Range{Offset: offset, Length: int64(len(data))})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the chunks complete in any order, sort them before merging
+	// the ones which follow each other
+	sort.Slice(found, func(i, j int) bool { return found[i].Offset < found[j].Offset })
+
+	merged := found[:0]
+	for _, rg := range found {
+		if n := len(merged); n > 0 && merged[n-1].Offset+merged[n-1].Length == rg.Offset {
+			merged[n-1].Length += rg.Length
+			continue
+		}
+		merged = append(merged, rg)
+	}
+	return merged, nil
+}
@@ -0,0 +1,74 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// Stats describes how a read went, so programs can record it
+// instead of parsing the log output of the command.
+//
+// Both the synchronous and the asynchronous reads fill it in;
+// a synchronous read is a single chunk read by a single goroutine.
+type Stats struct {
+	// BytesRead is the number of bytes read from the file.
+	BytesRead int64
+
+	// ChunkCount is the number of chunks the file was split into.
+	ChunkCount int
+
+	// GoroutinesUsed is the number of goroutines reading the file.
+	GoroutinesUsed int
+
+	// Duration is the wall clock time of the whole read,
+	// including opening the file.
+	Duration time.Duration
+
+	// Strategy is how the file was actually read.
+	Strategy Strategy
+
+	// ChunkSize is the size of the chunks of a read split into chunks,
+	// the one settled on by StrategyAdaptive.
+	ChunkSize int64
+
+	// ChunkDurations is the time taken by the ReadAt of every chunk,
+	// retries included, indexed by chunk. A slow region of the file
+	// stands out here. Only filled in with ReaderConfig.ChunkTiming,
+	// and only by reads split into chunks.
+	ChunkDurations []time.Duration
+}
+
+// FileMeta is the metadata of a file read by ReadAsyncMeta.
+type FileMeta struct {
+	// Size is the size of the file when the read started.
+	Size int64
+
+	// ModTime is the modification time of the file.
+	ModTime time.Time
+
+	// Mode is the mode and permission bits of the file.
+	Mode os.FileMode
+}
+
+func newFileMeta(fileStats os.FileInfo) FileMeta {
+	return FileMeta{
+		Size:    fileStats.Size(),
+		ModTime: fileStats.ModTime(),
+		Mode:    fileStats.Mode(),
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
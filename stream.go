@@ -0,0 +1,217 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// ScanAsync reads the file at path concurrently and calls fn with
+// every chunk, without ever holding the whole file in memory.
+//
+// The chunk buffers come from a pool and are reused once fn returns,
+// so fn must not keep data around. fn is called from several workers
+// at the same time, in no particular order. The first error returned
+// by fn stops the read and is returned.
+func ScanAsync(path string, fn func(offset int64, data []byte) error) error {
+	return defaultReader.ScanAsync(path, fn)
+}
+
+// ScanAsync is the package level ScanAsync using r's config.
+func (r *Reader) ScanAsync(path string, fn func(offset int64, data []byte) error) error {
+	return r.scan(path, func(src io.ReaderAt, size int64) error {
+		// every chunk gets its own pooled buffer, which goes back
+		// to the pool as soon as fn is done with it.
+		hooks := chunkHooks{
+			buffer: r.getBuffer,
+			handle: func(offset int64, data []byte) error {
+				defer r.putBuffer(data)
+				return fn(offset, data)
+			},
+			path: path,
+		}
+
+		_, err := r.readChunks(context.Background(), src, size, hooks)
+		return err
+	}, func(src io.Reader) error {
+		return r.readSequential(path, src, fn)
+	})
+}
+
+// ReadAsyncStream reads the file at path concurrently and calls fn with
+// every chunk in file order: offsets are increasing and every chunk
+// starts where the previous one ended. Chunks read ahead of time are
+// held back until all the chunks before them were passed to fn.
+//
+// fn is never called concurrently, but data is only valid until fn
+// returns. The first error returned by fn stops the read and is returned.
+func ReadAsyncStream(path string, fn func(offset int64, data []byte) error) error {
+	return defaultReader.ReadAsyncStream(path, fn)
+}
+
+// ReadAsyncStream is the package level ReadAsyncStream using r's config.
+func (r *Reader) ReadAsyncStream(path string, fn func(offset int64, data []byte) error) error {
+	return r.scan(path, func(src io.ReaderAt, size int64) error {
+		return r.readChunksOrdered(context.Background(), path, src, size, fn)
+	}, func(src io.Reader) error {
+		return r.readSequential(path, src, fn)
+	})
+}
+
+// scan opens the file at path and hands it to chunked, or to sequential
+// when the file is gzip compressed, or not a regular file, and can't be
+// read at random offsets.
+func (r *Reader) scan(path string, chunked func(src io.ReaderAt, size int64) error, sequential func(src io.Reader) error) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	file, fileStats, err := r.openFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return r.scanFile(file, fileStats, chunked, sequential)
+}
+
+// scanFile is scan for a file already open.
+func (r *Reader) scanFile(file *os.File, fileStats os.FileInfo, chunked func(src io.ReaderAt, size int64) error, sequential func(src io.Reader) error) error {
+	// like asyncReadFile, pipes and devices are read sequentially
+	if !fileStats.Mode().IsRegular() {
+		src, err := r.stripBOMReader(file)
+		if err != nil {
+			return readErr(file.Name(), PhaseRead, 0, err)
+		}
+		return sequential(src)
+	}
+
+	gz, err := r.gzipReader(file)
+	if err != nil {
+		return err
+	}
+	if gz != nil {
+		defer gz.Close()
+		src, err := r.stripBOMReader(gz)
+		if err != nil {
+			return readErr(file.Name(), PhaseRead, 0, err)
+		}
+		return sequential(src)
+	}
+
+	skip, err := r.skipBOM(file)
+	if err != nil {
+		return readErr(file.Name(), PhaseRead, 0, err)
+	}
+	size := fileStats.Size() - skip
+
+	if r.cfg.Strategy == StrategySequential {
+		err = sequential(io.NewSectionReader(file, skip, size))
+	} else {
+		src, _, release := r.source(file, fileStats.Size())
+		if skip > 0 {
+			src = io.NewSectionReader(src, skip, size)
+		}
+		err = chunked(src, size)
+		release()
+	}
+	if err != nil {
+		return err
+	}
+	return r.checkStable(file, fileStats)
+}
+
+// readChunksOrdered reads the chunks with the workers of readChunks but
+// calls fn with them in file order, from the calling goroutine. path is
+// the path of the file src comes from, for the errors.
+func (r *Reader) readChunksOrdered(ctx context.Context, path string, src io.ReaderAt, size int64, fn func(offset int64, data []byte) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		offset int64
+		data   []byte
+	}
+	// at most ReadAheadChunks chunks are read but not yet passed to fn,
+	// which bounds the memory held by the reorder buffer below. The
+	// results are buffered as much, so the workers can hand over their
+	// chunks and go on reading while fn is slow.
+	results := make(chan result, r.cfg.ReadAheadChunks)
+	window := make(chan struct{}, r.cfg.ReadAheadChunks)
+
+	hooks := chunkHooks{
+		buffer: r.getBuffer,
+		handle: func(offset int64, data []byte) error {
+			results <- result{offset, data}
+			return nil
+		},
+		window: window,
+		path:   path,
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := r.readChunks(ctx, src, size, hooks)
+		readErr <- err
+		close(results)
+	}()
+
+	// reorder buffer, chunks which arrived before the chunk at next.
+	// fn is only ever given the chunk starting exactly at next, which
+	// is what keeps the output in order whatever the read timings.
+	pending := make(map[int64][]byte)
+	next := int64(0)
+
+	var fnErr error
+	for res := range results {
+		// keep draining after an error so no worker stays blocked
+		if fnErr != nil {
+			r.putBuffer(res.data)
+			continue
+		}
+
+		pending[res.offset] = res.data
+		for data, ok := pending[next]; ok; data, ok = pending[next] {
+			delete(pending, next)
+
+			fnErr = fn(next, data)
+			next += int64(len(data))
+			r.putBuffer(data)
+			<-window
+
+			if fnErr != nil {
+				cancel()
+				break
+			}
+		}
+	}
+
+	err := <-readErr
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// getBuffer returns a chunk buffer of exactly length bytes. Full size
+// chunks get a pooled buffer, the shorter final chunk (or a file smaller
+// than a chunk) gets its own allocation of the right size instead.
+func (r *Reader) getBuffer(offset, length int64) []byte {
+	if length < r.cfg.ChunkSize {
+		return make([]byte, length)
+	}
+
+	buf := r.buffers.Get().(*[]byte)
+	return (*buf)[:length]
+}
+
+// putBuffer gives a buffer from getBuffer back to the pool,
+// only full size buffers are kept.
+func (r *Reader) putBuffer(data []byte) {
+	if int64(cap(data)) != r.cfg.ChunkSize {
+		return
+	}
+	r.buffers.Put(&data)
+}
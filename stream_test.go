@@ -0,0 +1,77 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+const benchChunkSize = 64 * 1024
+
+func BenchmarkScanAsync(b *testing.B) {
+	path := writeTmp(b, randData(256*benchChunkSize))
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: benchChunkSize})
+
+	b.SetBytes(256 * benchChunkSize)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := r.ScanAsync(path, func(offset int64, data []byte) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkScanAsyncAllocPerChunk is BenchmarkScanAsync with a buffer
+// allocated for every chunk instead of the pooled ones.
+func BenchmarkScanAsyncAllocPerChunk(b *testing.B) {
+	path := writeTmp(b, randData(256*benchChunkSize))
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: benchChunkSize})
+	hooks := chunkHooks{
+		buffer: func(offset, length int64) []byte { return make([]byte, length) },
+		handle: func(offset int64, data []byte) error { return nil },
+		path:   path,
+	}
+
+	b.SetBytes(256 * benchChunkSize)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, err = r.readChunks(context.Background(), file, 256*benchChunkSize, hooks)
+		file.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkReadAheadSlowConsumer reads chunks taking 200µs each to a
+// consumer just as slow, for a few ReadAheadChunks: the more chunks
+// read ahead, the more reads overlap with the consumer.
+func BenchmarkReadAheadSlowConsumer(b *testing.B) {
+	const chunks = 64
+	src := latencyReaderAt{200 * time.Microsecond}
+
+	for _, readAhead := range []int{1, 2, 8, 32} {
+		b.Run(fmt.Sprintf("readahead-%d", readAhead), func(b *testing.B) {
+			r := NewReader(ReaderConfig{ChunkSize: benchChunkSize, Concurrency: 8, ReadAheadChunks: readAhead})
+			b.SetBytes(chunks * benchChunkSize)
+			for i := 0; i < b.N; i++ {
+				err := r.readChunksOrdered(context.Background(), "", src, chunks*benchChunkSize, func(offset int64, data []byte) error {
+					time.Sleep(200 * time.Microsecond)
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
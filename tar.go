@@ -0,0 +1,77 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrEntryNotFound is returned by ReadTarEntry when the archive
+// has no entry of that name.
+var ErrEntryNotFound = errors.New("filereader: tar entry not found")
+
+// ReadTarEntry reads the data of the entry named entryName of the tar
+// archive at archivePath. Only the headers are read sequentially to find
+// the entry, its data is then read concurrently in chunks by ReadRange.
+//
+// The archive must not be compressed, a compressed stream can't be read
+// at random offsets, and the entry must be a regular file.
+func ReadTarEntry(archivePath, entryName string) ([]byte, error) {
+	return defaultReader.ReadTarEntry(archivePath, entryName)
+}
+
+// ReadTarEntry is the package level ReadTarEntry using r's config.
+func (r *Reader) ReadTarEntry(archivePath, entryName string) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	start, size, err := r.findTarEntry(archivePath, entryName)
+	if err != nil {
+		return nil, err
+	}
+	return r.ReadRange(archivePath, start, size)
+}
+
+// findTarEntry returns the offset and the size of the data of the
+// entry named entryName in the tar archive at archivePath.
+func (r *Reader) findTarEntry(archivePath, entryName string) (int64, int64, error) {
+	file, fileStats, err := r.openFile(archivePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	// a section reader can seek, so tar skips the data of the
+	// other entries instead of reading it, and tells where it is
+	archive := io.NewSectionReader(file, 0, fileStats.Size())
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			err := fmt.Errorf("%w: %s", ErrEntryNotFound, entryName)
+			return 0, 0, readErr(archivePath, PhaseOpen, -1, err)
+		}
+		if err != nil {
+			return 0, 0, readErr(archivePath, PhaseRead, -1, fmt.Errorf("cannot read tar archive: %w", err))
+		}
+		if hdr.Name != entryName {
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			err := fmt.Errorf("tar entry %s is not a regular file", entryName)
+			return 0, 0, readErr(archivePath, PhaseOpen, -1, err)
+		}
+
+		// the header was just consumed, the data follows it
+		start, err := archive.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, 0, readErr(archivePath, PhaseRead, -1, err)
+		}
+		return start, hdr.Size, nil
+	}
+}
@@ -0,0 +1,81 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// WriteAsync writes data to the file at path, creating it or truncating
+// it first, the way ReadAsync reads: data is split into chunks like a
+// read of the same size, which are written concurrently at their offset
+// with WriteAt. The file is synced to disk before WriteAsync returns.
+//
+// The first failing chunk stops the ones not started yet, the file is
+// then left partly written.
+func WriteAsync(path string, data []byte) error {
+	return defaultReader.WriteAsync(path, data)
+}
+
+// WriteAsync is the package level WriteAsync using r's config.
+func (r *Reader) WriteAsync(path string, data []byte) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	if path == "" {
+		return &ReadError{Phase: PhaseOpen, Offset: -1, Err: ErrEmptyPath}
+	}
+
+	file, err := os.OpenFile(longPath(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return &ReadError{Path: path, Phase: PhaseOpen, Offset: -1, Err: err}
+	}
+
+	err = r.writeChunks(path, file, data)
+	if err == nil {
+		if err = file.Sync(); err != nil {
+			err = readErr(path, PhaseWrite, -1, fmt.Errorf("cannot sync file: %w", err))
+		}
+	}
+	if cerr := file.Close(); err == nil && cerr != nil {
+		err = readErr(path, PhaseWrite, -1, fmt.Errorf("cannot close file: %w", cerr))
+	}
+	return err
+}
+
+// writeChunks writes data to dst, the file at path, in chunks of r's
+// chunk size, with at most r's concurrency goroutines at the same time.
+func (r *Reader) writeChunks(path string, dst io.WriterAt, data []byte) error {
+	grid := r.chunkGrid(0, int64(len(data)))
+
+	// the group cancels this context on the first error
+	// so no more chunks are dispatched.
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(r.concurrency())
+
+	for i := 0; i < grid.count; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		i, offset := i, grid.offset(i)
+		end := int64(len(data))
+		if i+1 < grid.count {
+			end = grid.offset(i + 1)
+		}
+		chunk := data[offset:end]
+
+		g.Go(func() error {
+			if _, err := dst.WriteAt(chunk, offset); err != nil {
+				return readErr(path, PhaseWrite, offset, fmt.Errorf("chunk %d: %w", i, err))
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
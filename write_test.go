@@ -0,0 +1,32 @@
+// copyright 2020 Probhonjon Baruah ( github.com/bigfoot31 ).
+
+package filereader
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAsyncRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	r := NewReader(ReaderConfig{SyncThreshold: -1, ChunkSize: 1000})
+
+	for _, size := range []int{0, 1, 999, 1000, 1001, 100*1000 + 7, 10} {
+		data := randData(size)
+		if err := r.WriteAsync(path, data); err != nil {
+			t.Fatalf("WriteAsync of %d bytes: %v", size, err)
+		}
+		// the file is truncated first, nothing of the previous
+		// bigger write is left
+		got, err := r.ReadAsync(path)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("ReadAsync after WriteAsync of %d bytes = %d bytes, %v", size, len(got), err)
+		}
+	}
+
+	if err := WriteAsync("", nil); !errors.Is(err, ErrEmptyPath) {
+		t.Errorf("WriteAsync to an empty path = %v, want ErrEmptyPath", err)
+	}
+}